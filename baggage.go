@@ -1,8 +1,9 @@
 package oteltag
 
 import (
+	"context"
 	"reflect"
-	"strings"
+	"strconv"
 
 	"go.opentelemetry.io/otel/baggage"
 
@@ -11,76 +12,275 @@ import (
 
 // BaggageMembers takes in a struct and spits out OpenTelemetry baggage members
 // based on the struct tags.
+//
+// Fields that cannot be converted are silently ignored, see [MarshalBaggage] for a strict alternative.
 func BaggageMembers(res any) []baggage.Member {
-	return structToBaggageMembers(res)
+	structValue, ok := structValue(reflect.ValueOf(res))
+	if !ok {
+		return nil
+	}
+
+	members, _ := defaultEncoder.rootToBaggageMembers(structValue, nil, false, false)
+	return members
 }
 
-// structToBaggageMembers returns a slice of [baggage.Member] for a struct.
-func structToBaggageMembers(s any) []baggage.Member {
-	structValue := reflect.ValueOf(s)
-	structType := structValue.Type()
+// MarshalBaggage takes in a struct (or a pointer to a struct) and returns its OpenTelemetry baggage members
+// based on the struct tags.
+//
+// Unlike [BaggageMembers], it returns an error instead of ignoring fields that cannot be converted:
+// an [*UnsupportedTypeError] for values or tagged fields of unsupported types,
+// an [*InvalidTagError] for tags holding unknown or malformed options,
+// an [*InvalidKeyError] for tags holding an invalid key and
+// an [*InvalidBaggageValueError] for values rejected by [baggage.NewMemberRaw].
+func MarshalBaggage(v any) ([]baggage.Member, error) {
+	structValue, err := marshalStructValue(v)
+	if err != nil || !structValue.IsValid() {
+		return nil, err
+	}
+
+	return defaultEncoder.rootToBaggageMembers(structValue, nil, false, true)
+}
 
-	// Handle pointer to struct
-	if structType.Kind() == reflect.Pointer {
-		if structValue.IsNil() {
-			return nil
+// rootToBaggageMembers returns the [baggage.Member] of a top-level struct.
+// If priorities is not nil, the priority of each member is appended to it.
+// If scoped is true, only the members of the fields scoped to the baggage are returned.
+func (e *Encoder) rootToBaggageMembers(structValue reflect.Value, priorities *[]int, scoped, strict bool) ([]baggage.Member, error) {
+	var members []baggage.Member
+	if e.planFor(structValue.Type(), "").baggager {
+		if !scoped {
+			members = appendMembers(nil, priorities, 0, customBaggageMembers(structValue)...)
+		}
+		if !e.rootMerge {
+			return members, nil
 		}
-		structValue = structValue.Elem()
-		structType = structValue.Type()
 	}
 
-	// Validate input is a struct
-	if structType.Kind() != reflect.Struct {
-		return nil
+	return e.structToBaggageMembers(structValue, "", 0, members, priorities, scoped, strict)
+}
+
+// structToBaggageMembers appends the [baggage.Member] of a struct located depth levels below the top-level struct
+// to members, prefixing the keys of its fields with prefix.
+// If priorities is not nil, the priority of each appended member is appended to it.
+// If scoped is true, only the fields scoped to the baggage, tagged with baggage or nested in such a field, are walked.
+// If strict is false, fields that cannot be converted are skipped instead of returning an error.
+func (e *Encoder) structToBaggageMembers(structValue reflect.Value, prefix string, depth int, members []baggage.Member, priorities *[]int, scoped, strict bool) ([]baggage.Member, error) {
+	plan := e.planFor(structValue.Type(), prefix)
+	if strict && plan.tagErr != nil {
+		return nil, plan.invalidTag()
+	}
+	if len(plan.fields) == 0 {
+		return members, nil
 	}
 
-	fieldCount := structValue.NumField()
-	if fieldCount == 0 {
-		return nil
+	if members == nil {
+		members = make([]baggage.Member, 0, len(plan.fields))
 	}
 
-	members := make([]baggage.Member, 0, fieldCount)
-	for i := 0; i < fieldCount; i++ {
-		field, fieldValue := structType.Field(i), structValue.Field(i)
-		if field.Type.Kind() == reflect.Struct && fieldValue.IsValid() {
-			members = append(members, structToBaggageMembers(fieldValue.Interface())...)
-		} else if field.Type.Kind() == reflect.Pointer && fieldValue.IsValid() && !fieldValue.IsNil() { // Known shortcoming, assuming a pointer can only be a struct.
-			members = append(members, structToBaggageMembers(fieldValue.Elem().Interface())...)
-		} else {
-			member := basicTypeToBaggageMember(structType, structValue, i)
-			if member.Key() == "" {
+	for i := range plan.fields {
+		field := &plan.fields[i]
+		if field.unexported && !e.unexportedFields || e.tooDeep(field, depth) {
+			continue
+		}
+
+		fieldValue := fieldValue(structValue, field.index)
+		if field.isZero != nil && field.isZero(fieldValue) {
+			continue
+		}
+
+		if field.node {
+			if internal.IsNil(fieldValue) {
 				continue
 			}
 
-			members = append(members, member)
+			// Fields nested in a node scoped to the baggage are all in scope.
+			nodeScoped := scoped && !field.baggageScope
+			if field.baggager {
+				if !nodeScoped {
+					members = appendMembers(members, priorities, field.priority, customBaggageMembers(fieldValue)...)
+				}
+				if !field.merge {
+					continue
+				}
+			}
+
+			if field.elem == nil {
+				continue
+			}
+			if field.pointer {
+				fieldValue = fieldValue.Elem()
+			}
+
+			var err error
+			members, err = e.structToBaggageMembers(fieldValue, field.prefix, depth+field.depth+1, members, priorities, nodeScoped, strict)
+			if err != nil {
+				return nil, withParentField(err, field.name)
+			}
+			continue
+		}
+
+		if scoped && !field.baggageScope {
+			continue
+		}
+
+		member, ok, err := basicTypeToBaggageMember(field, fieldValue)
+		if err != nil && strict {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+
+		var originalLength int
+		if field.max > 0 {
+			member, originalLength = e.truncateBaggageMember(field, member)
+		}
+
+		members = appendMembers(members, priorities, field.priority, member)
+		if field.truncatedKey != "" && originalLength >= 0 {
+			value := e.truncationMarker.value(originalLength).Emit()
+			if companion, err := baggage.NewMemberRaw(string(field.truncatedKey), value); err == nil {
+				members = appendMembers(members, priorities, field.priority, companion)
+			}
+		}
+		if field.keyIDMember.Key() != "" {
+			members = appendMembers(members, priorities, field.priority, field.keyIDMember)
 		}
 	}
 
-	return members
+	return members, nil
 }
 
-// basicTypeToBaggageMember returns an [attribute.Member] for a basic type.
-func basicTypeToBaggageMember(structType reflect.Type, structValue reflect.Value, index int) baggage.Member {
-	tag := internal.ExtractTag(structValue, index)
+// ContextWithBaggage returns a copy of ctx carrying its baggage merged with the members of v,
+// a struct or a pointer to a struct, based on the struct tags.
+//
+// Members whose key is already in the baggage of ctx are overwritten by the ones of v, unless another
+// policy is provided (only the last one counts).
+// It returns the same errors as [MarshalBaggage] and a [*BaggageConflictError] with [MergeError],
+// in which case ctx is returned unchanged.
+//
+// Unlike a [BaggageBuilder], it does not enforce the W3C limits.
+func ContextWithBaggage(ctx context.Context, v any, policy ...MergePolicy) (context.Context, error) {
+	return defaultEncoder.ContextWithBaggage(ctx, v, policy...)
+}
+
+// mergeBaggage returns bag with the provided members set according to policy.
+func mergeBaggage(bag baggage.Baggage, members []baggage.Member, policy MergePolicy) (baggage.Baggage, error) {
+	for _, member := range members {
+		existing := bag.Member(member.Key())
+		if existing.Key() != "" {
+			switch {
+			case policy == MergeKeepExisting:
+				continue
+			case policy == MergeError && existing.Value() != member.Value():
+				return bag, &BaggageConflictError{Key: member.Key(), Existing: existing.Value(), Value: member.Value()}
+			}
+		}
+
+		var err error
+		if bag, err = bag.SetMember(member); err != nil {
+			return bag, err
+		}
+	}
+
+	return bag, nil
+}
 
-	if tag == "" {
-		return baggage.Member{}
+// appendMembers appends add to members, and their priority to priorities if not nil.
+func appendMembers(members []baggage.Member, priorities *[]int, priority int, add ...baggage.Member) []baggage.Member {
+	if priorities != nil {
+		for range add {
+			*priorities = append(*priorities, priority)
+		}
 	}
 
-	var omitEmpty bool
-	before, after, found := strings.Cut(tag, ",")
-	if found {
-		tag = before
-		if after == flagOmitEmpty {
-			omitEmpty = true
+	return append(members, add...)
+}
+
+// basicTypeToBaggageMember returns a [baggage.Member] for a basic type.
+// Returns false if the field should not produce any member.
+func basicTypeToBaggageMember(field *fieldPlan, fieldValue reflect.Value) (baggage.Member, bool, error) {
+	if field.baggage == nil {
+		return baggage.Member{}, false, &UnsupportedTypeError{Field: field.name, Type: field.typ}
+	}
+	if !field.validMemberKey {
+		return baggage.Member{}, false, &InvalidKeyError{Field: field.name, Key: field.key}
+	}
+
+	member, zeroValue, err := field.baggage(field.key, fieldValue)
+	if zeroValue && field.omitEmpty {
+		return baggage.Member{}, false, nil
+	}
+	if err != nil {
+		var value string
+		if valueErr, ok := err.(*internal.BaggageValueError); ok {
+			value, err = valueErr.Value, valueErr.Err
 		}
+		return baggage.Member{}, false, &InvalidBaggageValueError{Field: field.name, Key: field.key, Value: value, Err: err}
+	}
+
+	return member, true, nil
+}
+
+// truncateBaggageMember returns member with a value truncated to the maximum length of the field.
+// Also returns the original length of a truncated value, -1 if it was not truncated.
+func (e *Encoder) truncateBaggageMember(field *fieldPlan, member baggage.Member) (baggage.Member, int) {
+	value, originalLength := internal.TruncateBaggageValue(member.Value(), field.max, field.joined, e.sliceSeparator)
+	if originalLength < 0 {
+		return member, originalLength
 	}
 
-	field, fieldValue := structType.Field(index), structValue.Field(index)
-	attr, zeroValue := internal.BaggageMember(field, fieldValue, tag)
-	if zeroValue && omitEmpty {
-		return baggage.Member{}
+	truncated, err := baggage.NewMemberRaw(member.Key(), value, member.Properties()...)
+	if err != nil {
+		return member, -1
 	}
+	return truncated, originalLength
+}
+
+// UnmarshalBaggage fills the struct pointed to by v with the members of the provided baggage,
+// based on the struct tags. It is the inverse of [BaggageMembers].
+//
+// Fields without a matching member, as well as redacted or masked fields, are left untouched.
+// Nil pointers to structs are allocated only if at least one of their fields has a matching member.
+// Returns an [*InvalidUnmarshalError] if v is not a non-nil pointer to a struct and
+// an [*UnmarshalTypeError] if a member value cannot be parsed into its field.
+func UnmarshalBaggage(bag baggage.Baggage, v any) error {
+	return defaultEncoder.UnmarshalBaggage(bag, v)
+}
+
+// FromContext returns a T filled with the baggage carried by ctx, see [UnmarshalBaggage].
+// T must be a struct type.
+func FromContext[T any](ctx context.Context) (T, error) {
+	var v T
+	err := UnmarshalBaggage(baggage.FromContext(ctx), &v)
+	return v, err
+}
+
+// baggageFieldDecoder returns a [decodeFieldFunc] setting fields from the baggage members.
+func baggageFieldDecoder(bag baggage.Baggage) decodeFieldFunc {
+	return func(field *fieldPlan, fieldValue reflect.Value) (bool, error) {
+		if !field.validMemberKey || field.redacted {
+			return false, nil
+		}
+
+		member := bag.Member(field.key)
+		if member.Key() == "" {
+			return false, nil
+		}
+
+		if field.parseBaggage == nil {
+			return false, &UnsupportedTypeError{Field: field.name, Type: field.typ}
+		}
 
-	return attr
+		if err := field.parseBaggage(member.Value(), fieldValue); err != nil {
+			return false, &UnmarshalTypeError{
+				Field: field.name,
+				Key:   field.key,
+				Value: strconv.Quote(member.Value()),
+				Type:  field.typ,
+				Err:   err,
+			}
+		}
+
+		return true, nil
+	}
 }
@@ -2,8 +2,18 @@ package oteltag_test
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/netip"
+	"net/url"
+	"reflect"
+	"slices"
 	"testing"
+	"time"
 
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/baggage"
 
 	oteltag "github.com/remychantenay/otel-tag"
@@ -115,6 +125,524 @@ func TestBaggageMembers(t *testing.T) {
 		}
 	})
 
+	t.Run("when pointers to basic types - should dereference them", func(t *testing.T) {
+		const wantMemberCount = 3
+
+		str, num := "a_string", 0
+
+		m := struct {
+			ValStr           *string `otel:"val_str,omitempty"`
+			ValIntZero       *int    `otel:"val_int_zero,omitempty"`
+			ValNilOmitted    *bool   `otel:"val_nil_omitted,omitempty"`
+			ValNilNotOmitted *[]int  `otel:"val_nil_not_omitted"`
+		}{
+			ValStr:     &str,
+			ValIntZero: &num,
+		}
+
+		want := map[string]string{
+			"val_str":             "a_string",
+			"val_int_zero":        "0",
+			"val_nil_not_omitted": "",
+		}
+
+		bag, _ := baggage.New(oteltag.BaggageMembers(m)...)
+
+		memberCount := len(bag.Members())
+		if memberCount != wantMemberCount {
+			t.Errorf("\ngot %d members\nwant %d", memberCount, wantMemberCount)
+		}
+
+		for k, v := range want {
+			member := bag.Member(k)
+			if member.Key() != k || member.Value() != v {
+				t.Errorf("\ngot %q for member %q\nwant %q", member.Value(), k, v)
+			}
+		}
+
+		var got struct {
+			ValStr     *string `otel:"val_str"`
+			ValIntZero *int    `otel:"val_int_zero"`
+			ValMissing *bool   `otel:"val_nil_omitted"`
+		}
+		if err := oteltag.UnmarshalBaggage(bag, &got); err != nil {
+			t.Fatalf("\ngot error %v\nwant nil", err)
+		}
+
+		if got.ValStr == nil || *got.ValStr != str || got.ValIntZero == nil || *got.ValIntZero != num || got.ValMissing != nil {
+			t.Errorf("\ngot %+v\nwant pointers to the member values", got)
+		}
+	})
+
+	t.Run("when all numeric kinds - should add members to baggage", func(t *testing.T) {
+		m := numericTestModel{
+			ValInt8:         -8,
+			ValInt16:        -16,
+			ValInt32:        -32,
+			ValUint:         1,
+			ValUint8:        8,
+			ValUint16:       16,
+			ValUint32:       32,
+			ValUint64:       64,
+			ValUint64Max:    math.MaxUint64,
+			ValFloat32:      0.1,
+			ValInt32Slice:   []int32{1, 2, 3},
+			ValUint16Slice:  []uint16{4, 5, 6},
+			ValUint64Slice:  []uint64{7, math.MaxUint64},
+			ValFloat32Slice: []float32{1.1, 2.2},
+		}
+
+		want := map[string]string{
+			"val_int8":          "-8",
+			"val_int16":         "-16",
+			"val_int32":         "-32",
+			"val_uint":          "1",
+			"val_uint8":         "8",
+			"val_uint16":        "16",
+			"val_uint32":        "32",
+			"val_uint64":        "64",
+			"val_uint64_max":    "18446744073709551615",
+			"val_float32":       "0.1",
+			"val_int32_slice":   "1,2,3",
+			"val_uint16_slice":  "4,5,6",
+			"val_uint64_slice":  "7,18446744073709551615",
+			"val_float32_slice": "1.1,2.2",
+		}
+
+		bag, _ := baggage.New(oteltag.BaggageMembers(m)...)
+
+		memberCount := len(bag.Members())
+		if memberCount != len(want) {
+			t.Errorf("\ngot %d members\nwant %d", memberCount, len(want))
+		}
+
+		for k, v := range want {
+			member := bag.Member(k)
+			if member.Value() != v {
+				t.Errorf("\ngot %q for member %q\nwant %q", member.Value(), k, v)
+			}
+		}
+
+		var decoded numericTestModel
+		if err := oteltag.UnmarshalBaggage(bag, &decoded); err != nil {
+			t.Fatalf("\ngot error %v\nwant nil", err)
+		}
+
+		if !reflect.DeepEqual(decoded, m) {
+			t.Errorf("\ngot %+v\nwant %+v", decoded, m)
+		}
+	})
+
+	t.Run("when named types - should add members to baggage", func(t *testing.T) {
+		m := namedTestModel{
+			Role:   "admin",
+			Roles:  testRoles{"admin", "editor"},
+			Levels: testLevels{1, 2},
+			Ratios: []testRatio{0.5, 1.5},
+			Flags:  []testFlag{true, false},
+		}
+
+		want := map[string]string{
+			"role":   "admin",
+			"roles":  "admin,editor",
+			"levels": "1,2",
+			"ratios": "0.5,1.5",
+			"flags":  "true,false",
+		}
+
+		bag, _ := baggage.New(oteltag.BaggageMembers(m)...)
+
+		memberCount := len(bag.Members())
+		if memberCount != len(want) {
+			t.Errorf("\ngot %d members\nwant %d", memberCount, len(want))
+		}
+
+		for k, v := range want {
+			member := bag.Member(k)
+			if member.Value() != v {
+				t.Errorf("\ngot %q for member %q\nwant %q", member.Value(), k, v)
+			}
+		}
+
+		var decoded namedTestModel
+		if err := oteltag.UnmarshalBaggage(bag, &decoded); err != nil {
+			t.Fatalf("\ngot error %v\nwant nil", err)
+		}
+
+		if !reflect.DeepEqual(decoded, m) {
+			t.Errorf("\ngot %+v\nwant %+v", decoded, m)
+		}
+	})
+
+	t.Run("when unexported and embedded fields - should skip unexported fields without panicking", func(t *testing.T) {
+		want := map[string]string{
+			"base.id": "123",
+			"name":    "john_doe",
+		}
+
+		bag, _ := baggage.New(oteltag.BaggageMembers(newUnexportedTestModel())...)
+
+		memberCount := len(bag.Members())
+		if memberCount != len(want) {
+			t.Errorf("\ngot %d members\nwant %d", memberCount, len(want))
+		}
+
+		for k, v := range want {
+			member := bag.Member(k)
+			if member.Value() != v {
+				t.Errorf("\ngot %q for member %q\nwant %q", member.Value(), k, v)
+			}
+		}
+
+		var decoded unexportedTestModel
+		if err := oteltag.UnmarshalBaggage(bag, &decoded); err != nil {
+			t.Fatalf("\ngot error %v\nwant nil", err)
+		}
+
+		if decoded.ID != "123" || decoded.Name != "john_doe" {
+			t.Errorf("\ngot ID %q and name %q\nwant %q and %q", decoded.ID, decoded.Name, "123", "john_doe")
+		}
+	})
+
+	t.Run("when unexported fields and opted-in - should add them to baggage", func(t *testing.T) {
+		want := map[string]string{
+			"base.id":     "123",
+			"details.bio": "dev",
+			"tags":        "a,b",
+			"name":        "john_doe",
+		}
+
+		bag, _ := baggage.New(oteltag.NewEncoder(oteltag.WithUnexportedFields()).BaggageMembers(newUnexportedTestModel())...)
+
+		memberCount := len(bag.Members())
+		if memberCount != len(want) {
+			t.Errorf("\ngot %d members\nwant %d", memberCount, len(want))
+		}
+
+		for k, v := range want {
+			member := bag.Member(k)
+			if member.Value() != v {
+				t.Errorf("\ngot %q for member %q\nwant %q", member.Value(), k, v)
+			}
+		}
+	})
+
+	t.Run("when time and duration fields - should format them as specified", func(t *testing.T) {
+		createdAt := time.Date(2024, time.March, 1, 12, 30, 45, 123000000, time.UTC)
+		createdOn := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+		m := timeTestModel{
+			CreatedAt:     createdAt,
+			CreatedAtUnix: createdAt.Truncate(time.Second),
+			CreatedAtMs:   createdAt,
+			CreatedOn:     &createdOn,
+			Timeout:       1500 * time.Millisecond,
+			TimeoutMs:     1500 * time.Millisecond,
+			TimeoutS:      1500 * time.Millisecond,
+		}
+
+		want := map[string]string{
+			"created_at":      "2024-03-01T12:30:45.123Z",
+			"created_at_unix": "1709296245",
+			"created_at_ms":   "1709296245123",
+			"created_on":      "2024-03-01",
+			"timeout":         "1500000000",
+			"timeout_ms":      "1500",
+			"timeout_s":       "1.5",
+		}
+
+		bag, _ := baggage.New(oteltag.BaggageMembers(m)...)
+
+		memberCount := len(bag.Members())
+		if memberCount != len(want) {
+			t.Errorf("\ngot %d members\nwant %d", memberCount, len(want))
+		}
+
+		for k, v := range want {
+			member := bag.Member(k)
+			if member.Value() != v {
+				t.Errorf("\ngot %q for member %q\nwant %q", member.Value(), k, v)
+			}
+		}
+
+		var decoded timeTestModel
+		if err := oteltag.UnmarshalBaggage(bag, &decoded); err != nil {
+			t.Fatalf("\ngot error %v\nwant nil", err)
+		}
+
+		if !equalTimeTestModels(decoded, m) {
+			t.Errorf("\ngot %+v\nwant %+v", decoded, m)
+		}
+	})
+
+	t.Run("when nil stringers - should add empty values without panicking", func(t *testing.T) {
+		u, _ := url.Parse("https://example.com")
+		m := struct {
+			Nil      fmt.Stringer `otel:"nil"`
+			TypedNil fmt.Stringer `otel:"typed_nil"`
+			URLs     []*url.URL   `otel:"urls"`
+		}{
+			TypedNil: (*url.URL)(nil),
+			URLs:     []*url.URL{nil, u},
+		}
+
+		want := []string{"nil=", "typed_nil=", "urls=,https://example.com"}
+
+		members := oteltag.BaggageMembers(m)
+
+		got := make([]string, len(members))
+		for i, m := range members {
+			got[i] = m.Key() + "=" + m.Value()
+		}
+
+		if !slices.Equal(got, want) {
+			t.Errorf("\ngot %v\nwant %v", got, want)
+		}
+	})
+
+	t.Run("when text marshalers and stringers - should add string members to baggage", func(t *testing.T) {
+		m := textTestModel{
+			Status:    1,
+			StatusRaw: 2,
+			Statuses:  []testStatus{1, 2},
+			ID:        testID{0xde, 0xad, 0xbe, 0xef},
+			IP:        net.IPv4(192, 168, 0, 1),
+			Addr:      netip.MustParseAddr("10.0.0.1"),
+			URL:       url.URL{Scheme: "https", Host: "example.com", Path: "/users"},
+		}
+
+		want := map[string]string{
+			"status":     "active",
+			"status_raw": "2",
+			"statuses":   "active,suspended",
+			"id":         "deadbeef",
+			"ip":         "192.168.0.1",
+			"addr":       "10.0.0.1",
+			"url":        "https://example.com/users",
+		}
+
+		bag, _ := baggage.New(oteltag.BaggageMembers(m)...)
+
+		memberCount := len(bag.Members())
+		if memberCount != len(want) {
+			t.Errorf("\ngot %d members\nwant %d", memberCount, len(want))
+		}
+
+		for k, v := range want {
+			member := bag.Member(k)
+			if member.Value() != v {
+				t.Errorf("\ngot %q for member %q\nwant %q", member.Value(), k, v)
+			}
+		}
+
+		var decoded struct {
+			StatusRaw testStatus `otel:"status_raw,raw"`
+			IP        net.IP     `otel:"ip"`
+			Addr      netip.Addr `otel:"addr"`
+		}
+		if err := oteltag.UnmarshalBaggage(bag, &decoded); err != nil {
+			t.Fatalf("\ngot error %v\nwant nil", err)
+		}
+
+		if decoded.StatusRaw != m.StatusRaw || !decoded.IP.Equal(m.IP) || decoded.Addr != m.Addr {
+			t.Errorf("\ngot %+v\nwant status %v, IP %v and addr %v", decoded, m.StatusRaw, m.IP, m.Addr)
+		}
+	})
+
+	t.Run("when baggagers - should replace or merge their members", func(t *testing.T) {
+		want := []string{"cart.size=2", "cart.id=c1", "cart.items=1,2", "cart.size=1", "name=john_doe"}
+
+		m := customTestModel{
+			Cart:        testCart{ID: "c1", Items: []int64{1, 2}},
+			ReplaceCart: &testCart{ID: "c2", Items: []int64{3}},
+			Name:        "john_doe",
+		}
+
+		members := oteltag.BaggageMembers(m)
+
+		got := make([]string, len(members))
+		for i, m := range members {
+			got[i] = m.Key() + "=" + m.Value()
+		}
+
+		if !slices.Equal(got, want) {
+			t.Errorf("\ngot %v\nwant %v", got, want)
+		}
+	})
+
+	t.Run("when nil baggager interfaces - should skip them without panicking", func(t *testing.T) {
+		want := []string{"name=john_doe"}
+
+		m := struct {
+			Nil      oteltag.Baggager `otel:"nil"`
+			TypedNil oteltag.Baggager `otel:"typed_nil"`
+			Name     string           `otel:"name"`
+		}{
+			TypedNil: (*testCart)(nil),
+			Name:     "john_doe",
+		}
+
+		members := oteltag.BaggageMembers(m)
+
+		got := make([]string, len(members))
+		for i, m := range members {
+			got[i] = m.Key() + "=" + m.Value()
+		}
+
+		if !slices.Equal(got, want) {
+			t.Errorf("\ngot %v\nwant %v", got, want)
+		}
+	})
+
+	t.Run("when omitzero - should skip zero values and zero nested structs", func(t *testing.T) {
+		want := []string{"order.id=7", "shipment.carrier=ups", "shipment.weight=0", "order.count=0"}
+
+		members := oteltag.BaggageMembers(zeroTestModel{ID: 7, Shipment: testShipment{Carrier: "ups"}})
+
+		got := make([]string, len(members))
+		for i, m := range members {
+			got[i] = m.Key() + "=" + m.Value()
+		}
+
+		if !slices.Equal(got, want) {
+			t.Errorf("\ngot %v\nwant %v", got, want)
+		}
+	})
+
+	t.Run("when redacted and masked fields - should hide their values", func(t *testing.T) {
+		want := []string{
+			"user.email=[REDACTED]",
+			"user.age=[REDACTED]",
+			"user.card=************1111",
+			"user.phone=061*******",
+			"user.pin=****",
+			"user.tokens=**cd,**gh",
+			"user.username=john_doe",
+		}
+
+		members := oteltag.BaggageMembers(newRedactTestModel())
+
+		got := make([]string, len(members))
+		for i, m := range members {
+			got[i] = m.Key() + "=" + m.Value()
+		}
+
+		if !slices.Equal(got, want) {
+			t.Errorf("\ngot %v\nwant %v", got, want)
+		}
+	})
+
+	t.Run("when masked slices - should mask each element like span attributes", func(t *testing.T) {
+		m := struct {
+			Tokens []string `otel:"tokens,mask=last2"`
+			Codes  []int    `otel:"codes,mask=first1"`
+		}{
+			Tokens: []string{"abcd", "efgh"},
+			Codes:  []int{123, 456},
+		}
+
+		wantAttrs := []attribute.KeyValue{
+			attribute.StringSlice("tokens", []string{"**cd", "**gh"}),
+			attribute.StringSlice("codes", []string{"1**", "4**"}),
+		}
+		want := []string{"tokens=**cd,**gh", "codes=1**,4**"}
+
+		if got := oteltag.SpanAttributes(m); !slices.Equal(got, wantAttrs) {
+			t.Errorf("\ngot %v\nwant %v", got, wantAttrs)
+		}
+
+		members := oteltag.BaggageMembers(m)
+
+		got := make([]string, len(members))
+		for i, m := range members {
+			got[i] = m.Key() + "=" + m.Value()
+		}
+
+		if !slices.Equal(got, want) {
+			t.Errorf("\ngot %v\nwant %v", got, want)
+		}
+	})
+
+	t.Run("when max lengths - should truncate strings and cap slices", func(t *testing.T) {
+		want := []string{
+			"order.description=crème",
+			"order.note=short",
+			"order.tags=a,b",
+			"order.ids=1,2",
+			"order.title=a long title",
+		}
+
+		members := oteltag.BaggageMembers(newTruncateTestModel())
+
+		got := make([]string, len(members))
+		for i, m := range members {
+			got[i] = m.Key() + "=" + m.Value()
+		}
+
+		if !slices.Equal(got, want) {
+			t.Errorf("\ngot %v\nwant %v", got, want)
+		}
+	})
+
+	t.Run("when member properties - should add the tag and value properties", func(t *testing.T) {
+		want := []string{
+			"user.email=jane@example.com;sensitive;ttl=60",
+			"user.bio=long;internal",
+			"user.region=eu;sensitive;residency=eu",
+			"user.home=",
+			"user.username=jane",
+		}
+
+		type user struct {
+			Email    string      `otel:"user.email,prop=sensitive,prop=ttl:60"`
+			Bio      string      `otel:"user.bio,max=4,prop=internal"`
+			Region   testRegion  `otel:"user.region,prop=sensitive"`
+			Home     *testRegion `otel:"user.home"`
+			Username string      `otel:"user.username"`
+		}
+
+		m := user{
+			Email:    "jane@example.com",
+			Bio:      "long bio",
+			Region:   "eu",
+			Username: "jane",
+		}
+
+		members := oteltag.BaggageMembers(m)
+
+		got := make([]string, len(members))
+		for i, m := range members {
+			got[i] = m.String()
+		}
+
+		if !slices.Equal(got, want) {
+			t.Errorf("\ngot %v\nwant %v", got, want)
+		}
+	})
+
+	t.Run("when prefixed nested structs - should prefix the keys of their fields", func(t *testing.T) {
+		want := []string{
+			"id=42",
+			"app.home.city=Paris",
+			"app.home.geo.country=FR",
+			"app.work.city=Lyon",
+			"app.work.geo.country=FR",
+			"city=Nice",
+			"geo.country=FR",
+		}
+
+		members := oteltag.BaggageMembers(newPrefixTestModel())
+
+		got := make([]string, len(members))
+		for i, m := range members {
+			got[i] = m.Key() + "=" + m.Value()
+		}
+
+		if !slices.Equal(got, want) {
+			t.Errorf("\ngot %v\nwant %v", got, want)
+		}
+	})
+
 	t.Run("when fields are not tagged - should not add members to baggage", func(t *testing.T) {
 		const wantMemberCount = 0
 
@@ -135,3 +663,458 @@ func TestBaggageMembers(t *testing.T) {
 		}
 	})
 }
+
+func TestMarshalBaggage(t *testing.T) {
+	t.Run("when valid struct - should return all members", func(t *testing.T) {
+		const wantMemberCount = 2
+
+		m := testModel{ValStr: "a_string", ValInt: 42}
+
+		members, err := oteltag.MarshalBaggage(m)
+		if err != nil {
+			t.Fatalf("\ngot error %v\nwant nil", err)
+		}
+
+		if len(members) != wantMemberCount {
+			t.Errorf("\ngot %d members\nwant %d", len(members), wantMemberCount)
+		}
+	})
+
+	t.Run("when not a struct - should return an unsupported type error", func(t *testing.T) {
+		_, err := oteltag.MarshalBaggage("a_string")
+
+		var typeErr *oteltag.UnsupportedTypeError
+		if !errors.As(err, &typeErr) {
+			t.Fatalf("\ngot error %v\nwant %T", err, typeErr)
+		}
+	})
+
+	t.Run("when invalid tag option - should return an invalid tag error", func(t *testing.T) {
+		m := struct {
+			ValStr string `otel:"val_str,omitmpty"`
+		}{}
+
+		_, err := oteltag.MarshalBaggage(m)
+
+		var tagErr *oteltag.InvalidTagError
+		if !errors.As(err, &tagErr) {
+			t.Fatalf("\ngot error %v\nwant %T", err, tagErr)
+		}
+
+		if tagErr.Field != "ValStr" || !errors.Is(err, oteltag.ErrUnknownTagOption) {
+			t.Errorf("\ngot %v\nwant field ValStr and %v", err, oteltag.ErrUnknownTagOption)
+		}
+
+		if members := oteltag.BaggageMembers(m); len(members) != 1 {
+			t.Errorf("\ngot %d members from BaggageMembers\nwant 1", len(members))
+		}
+	})
+
+	t.Run("when invalid property - should return an invalid tag error", func(t *testing.T) {
+		m := struct {
+			ValStr string `otel:"val_str,prop=in valid"`
+		}{}
+
+		_, err := oteltag.MarshalBaggage(m)
+
+		var tagErr *oteltag.InvalidTagError
+		if !errors.As(err, &tagErr) {
+			t.Fatalf("\ngot error %v\nwant %T", err, tagErr)
+		}
+
+		if tagErr.Option != "prop=in valid" || !errors.Is(err, oteltag.ErrMalformedTagOption) {
+			t.Errorf("\ngot %v\nwant option prop=in valid and %v", err, oteltag.ErrMalformedTagOption)
+		}
+	})
+
+	t.Run("when invalid value - should return an invalid baggage value error", func(t *testing.T) {
+		const wantValue = "\xff"
+
+		m := struct {
+			ValStr string `otel:"val_str"`
+		}{
+			ValStr: wantValue,
+		}
+
+		_, err := oteltag.MarshalBaggage(m)
+
+		var valueErr *oteltag.InvalidBaggageValueError
+		if !errors.As(err, &valueErr) {
+			t.Fatalf("\ngot error %v\nwant %T", err, valueErr)
+		}
+
+		if valueErr.Field != "ValStr" || valueErr.Value != wantValue {
+			t.Errorf("\ngot field %q and value %q\nwant %q and %q", valueErr.Field, valueErr.Value, "ValStr", wantValue)
+		}
+
+		if members := oteltag.BaggageMembers(m); len(members) != 0 {
+			t.Errorf("\ngot %d members from BaggageMembers\nwant 0", len(members))
+		}
+	})
+}
+
+func TestContextWithBaggage(t *testing.T) {
+	type user struct {
+		TenantID string `otel:"app.tenant.id"`
+		ID       string `otel:"app.user.id"`
+	}
+
+	// newContext returns a context carrying app.tenant.id=acme and app.request.id=r1.
+	newContext := func(t *testing.T) context.Context {
+		t.Helper()
+
+		bag, err := baggage.Parse("app.tenant.id=acme,app.request.id=r1")
+		if err != nil {
+			t.Fatalf("\ngot error %v\nwant nil", err)
+		}
+		return baggage.ContextWithBaggage(context.Background(), bag)
+	}
+
+	// members returns the members carried by ctx as sorted key=value pairs.
+	members := func(ctx context.Context) []string {
+		var got []string
+		for _, m := range baggage.FromContext(ctx).Members() {
+			got = append(got, m.Key()+"="+m.Value())
+		}
+		slices.Sort(got)
+		return got
+	}
+
+	t.Run("when default policy - should merge and overwrite existing members", func(t *testing.T) {
+		want := []string{"app.request.id=r1", "app.tenant.id=globex", "app.user.id=42"}
+
+		ctx, err := oteltag.ContextWithBaggage(newContext(t), user{TenantID: "globex", ID: "42"})
+		if err != nil {
+			t.Fatalf("\ngot error %v\nwant nil", err)
+		}
+
+		if got := members(ctx); !slices.Equal(got, want) {
+			t.Errorf("\ngot %v\nwant %v", got, want)
+		}
+	})
+
+	t.Run("when keep existing policy - should not overwrite existing members", func(t *testing.T) {
+		want := []string{"app.request.id=r1", "app.tenant.id=acme", "app.user.id=42"}
+
+		enc := oteltag.NewEncoder(oteltag.WithMergePolicy(oteltag.MergeKeepExisting))
+		ctx, err := enc.ContextWithBaggage(newContext(t), user{TenantID: "globex", ID: "42"})
+		if err != nil {
+			t.Fatalf("\ngot error %v\nwant nil", err)
+		}
+
+		if got := members(ctx); !slices.Equal(got, want) {
+			t.Errorf("\ngot %v\nwant %v", got, want)
+		}
+	})
+
+	t.Run("when policy provided per call - should override the one of the encoder", func(t *testing.T) {
+		want := []string{"app.request.id=r1", "app.tenant.id=acme", "app.user.id=42"}
+
+		ctx, err := oteltag.ContextWithBaggage(newContext(t), user{TenantID: "globex", ID: "42"}, oteltag.MergeKeepExisting)
+		if err != nil {
+			t.Fatalf("\ngot error %v\nwant nil", err)
+		}
+
+		if got := members(ctx); !slices.Equal(got, want) {
+			t.Errorf("\ngot %v\nwant %v", got, want)
+		}
+
+		enc := oteltag.NewEncoder(oteltag.WithMergePolicy(oteltag.MergeError))
+		if _, err := enc.ContextWithBaggage(newContext(t), user{TenantID: "globex", ID: "42"}, oteltag.MergeOverwrite); err != nil {
+			t.Errorf("\ngot error %v\nwant nil", err)
+		}
+	})
+
+	t.Run("when error policy and same value - should merge members", func(t *testing.T) {
+		want := []string{"app.request.id=r1", "app.tenant.id=acme", "app.user.id=42"}
+
+		enc := oteltag.NewEncoder(oteltag.WithMergePolicy(oteltag.MergeError))
+		ctx, err := enc.ContextWithBaggage(newContext(t), user{TenantID: "acme", ID: "42"})
+		if err != nil {
+			t.Fatalf("\ngot error %v\nwant nil", err)
+		}
+
+		if got := members(ctx); !slices.Equal(got, want) {
+			t.Errorf("\ngot %v\nwant %v", got, want)
+		}
+	})
+
+	t.Run("when error policy and conflicting value - should return a conflict error", func(t *testing.T) {
+		want := oteltag.BaggageConflictError{Key: "app.tenant.id", Existing: "acme", Value: "globex"}
+
+		parent := newContext(t)
+		enc := oteltag.NewEncoder(oteltag.WithMergePolicy(oteltag.MergeError))
+		ctx, err := enc.ContextWithBaggage(parent, user{TenantID: "globex", ID: "42"})
+
+		var conflictErr *oteltag.BaggageConflictError
+		if !errors.As(err, &conflictErr) {
+			t.Fatalf("\ngot error %v\nwant %T", err, conflictErr)
+		}
+		if *conflictErr != want {
+			t.Errorf("\ngot %+v\nwant %+v", *conflictErr, want)
+		}
+		if ctx != parent {
+			t.Errorf("\ngot a new context\nwant the provided one")
+		}
+	})
+
+	t.Run("when invalid value - should return an invalid baggage value error", func(t *testing.T) {
+		parent := newContext(t)
+		ctx, err := oteltag.ContextWithBaggage(parent, user{TenantID: "\xff"})
+
+		var valueErr *oteltag.InvalidBaggageValueError
+		if !errors.As(err, &valueErr) {
+			t.Fatalf("\ngot error %v\nwant %T", err, valueErr)
+		}
+		if ctx != parent {
+			t.Errorf("\ngot a new context\nwant the provided one")
+		}
+	})
+
+	t.Run("when nil pointer - should return the provided context", func(t *testing.T) {
+		parent := newContext(t)
+		ctx, err := oteltag.ContextWithBaggage(parent, (*user)(nil))
+		if err != nil {
+			t.Fatalf("\ngot error %v\nwant nil", err)
+		}
+		if ctx != parent {
+			t.Errorf("\ngot a new context\nwant the provided one")
+		}
+	})
+}
+
+func TestUnmarshalBaggage(t *testing.T) {
+	t.Run("when members produced by BaggageMembers - should fill all fields", func(t *testing.T) {
+		want := testModel{
+			ValStr:          "a_string",
+			ValInt:          42,
+			ValInt64:        42000000000,
+			ValFloat64:      99.718281828,
+			ValBool:         true,
+			ValStrSlice:     []string{"a_string_1", "a_string_2", "a_string_3"},
+			ValIntSlice:     []int{1, 2, 3},
+			ValInt64Slice:   []int64{100000, 200000, 300000},
+			ValFloat64Slice: []float64{1.1, 2.2, 3.3},
+			ValBoolSlice:    []bool{true, false, true, false},
+		}
+
+		bag, err := baggage.New(oteltag.BaggageMembers(want)...)
+		if err != nil {
+			t.Fatalf("\ngot error %v\nwant nil", err)
+		}
+
+		var got testModel
+		if err := oteltag.UnmarshalBaggage(bag, &got); err != nil {
+			t.Fatalf("\ngot error %v\nwant nil", err)
+		}
+
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("\ngot %+v\nwant %+v", got, want)
+		}
+	})
+
+	t.Run("when nested and pointer structs - should fill them", func(t *testing.T) {
+		type address struct {
+			City string `otel:"app.address.city"`
+		}
+
+		type details struct {
+			Bio string `otel:"app.details.bio"`
+		}
+
+		type company struct {
+			Name string `otel:"app.company.name"`
+		}
+
+		type user struct {
+			ID      int `otel:"app.user.id"`
+			Address address
+			Details *details
+			Company *company
+		}
+
+		member := func(k, v string) baggage.Member {
+			m, _ := baggage.NewMemberRaw(k, v)
+			return m
+		}
+
+		bag, _ := baggage.New(
+			member("app.user.id", "7"),
+			member("app.address.city", "Paris"),
+			member("app.details.bio", "dev"),
+		)
+		ctx := baggage.ContextWithBaggage(context.Background(), bag)
+
+		got, err := oteltag.FromContext[user](ctx)
+		if err != nil {
+			t.Fatalf("\ngot error %v\nwant nil", err)
+		}
+
+		if got.ID != 7 || got.Address.City != "Paris" || got.Details == nil || got.Details.Bio != "dev" {
+			t.Errorf("\ngot %+v\nwant all fields set", got)
+		}
+
+		if got.Company != nil {
+			t.Errorf("\ngot %+v\nwant nil company", got.Company)
+		}
+	})
+
+	t.Run("when prefixed nested structs - should fill them", func(t *testing.T) {
+		want := newPrefixTestModel()
+
+		bag, _ := baggage.New(oteltag.BaggageMembers(want)...)
+
+		var got prefixTestModel
+		if err := oteltag.UnmarshalBaggage(bag, &got); err != nil {
+			t.Fatalf("\ngot error %v\nwant nil", err)
+		}
+
+		if got.ID != want.ID || got.Home != want.Home || got.Work == nil || *got.Work != *want.Work || got.Shipping != want.Shipping {
+			t.Errorf("\ngot %+v\nwant %+v", got, want)
+		}
+	})
+
+	t.Run("when special characters propagated - should round-trip losslessly", func(t *testing.T) {
+		type model struct {
+			Query string   `otel:"query"`
+			Tags  []string `otel:"tags"`
+			Names []string `otel:"names"`
+		}
+
+		want := model{
+			Query: "a=1;b=2, c=100% café",
+			Tags:  []string{"a,b", "100%", "%2C", "", "naïve"},
+			Names: []string{"x|y"},
+		}
+
+		for _, enc := range []*oteltag.Encoder{oteltag.NewEncoder(), oteltag.NewEncoder(oteltag.WithSliceSeparator("|"))} {
+			bag, err := baggage.New(enc.BaggageMembers(want)...)
+			if err != nil {
+				t.Fatalf("\ngot error %v\nwant nil", err)
+			}
+
+			// Propagated through the W3C baggage header.
+			bag, err = baggage.Parse(bag.String())
+			if err != nil {
+				t.Fatalf("\ngot error %v\nwant nil", err)
+			}
+
+			var got model
+			if err := enc.UnmarshalBaggage(bag, &got); err != nil {
+				t.Fatalf("\ngot error %v\nwant nil", err)
+			}
+
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("\ngot %+v\nwant %+v", got, want)
+			}
+		}
+	})
+
+	t.Run("when single empty element or percent sign - should round-trip losslessly", func(t *testing.T) {
+		type model struct {
+			Empty   []string `otel:"empty"`
+			Percent []string `otel:"percent"`
+			None    []string `otel:"none"`
+		}
+
+		want := model{
+			Empty:   []string{""},
+			Percent: []string{"%"},
+		}
+
+		bag, err := baggage.New(oteltag.BaggageMembers(want)...)
+		if err != nil {
+			t.Fatalf("\ngot error %v\nwant nil", err)
+		}
+
+		bag, err = baggage.Parse(bag.String())
+		if err != nil {
+			t.Fatalf("\ngot error %v\nwant nil", err)
+		}
+
+		var got model
+		if err := oteltag.UnmarshalBaggage(bag, &got); err != nil {
+			t.Fatalf("\ngot error %v\nwant nil", err)
+		}
+
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("\ngot %#v\nwant %#v", got, want)
+		}
+	})
+
+	t.Run("when custom slice separator - should round-trip losslessly", func(t *testing.T) {
+		type model struct {
+			Tags  []string `otel:"tags"`
+			Empty []string `otel:"empty"`
+		}
+
+		want := model{
+			Tags:  []string{"a|b", "c%d", "e,f", ""},
+			Empty: []string{""},
+		}
+
+		for _, sep := range []string{"|", "%", "25", "a"} {
+			enc := oteltag.NewEncoder(oteltag.WithSliceSeparator(sep))
+
+			bag, err := baggage.New(enc.BaggageMembers(want)...)
+			if err != nil {
+				t.Fatalf("\ngot error %v\nwant nil", err)
+			}
+
+			var got model
+			if err := enc.UnmarshalBaggage(bag, &got); err != nil {
+				t.Fatalf("\ngot error %v\nwant nil", err)
+			}
+
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("separator %q\ngot %#v\nwant %#v", sep, got, want)
+			}
+		}
+	})
+
+	t.Run("when slice capped to a single empty element - should decode it", func(t *testing.T) {
+		type model struct {
+			S []string `otel:"s,max=1"`
+		}
+
+		bag, err := baggage.New(oteltag.BaggageMembers(model{S: []string{"", "x"}})...)
+		if err != nil {
+			t.Fatalf("\ngot error %v\nwant nil", err)
+		}
+
+		var got model
+		if err := oteltag.UnmarshalBaggage(bag, &got); err != nil {
+			t.Fatalf("\ngot error %v\nwant nil", err)
+		}
+
+		if want := (model{S: []string{""}}); !reflect.DeepEqual(got, want) {
+			t.Errorf("\ngot %#v\nwant %#v", got, want)
+		}
+	})
+
+	t.Run("when invalid member value - should return an unmarshal type error", func(t *testing.T) {
+		m, _ := baggage.NewMemberRaw("val_int_slice", "1,a,3")
+		bag, _ := baggage.New(m)
+
+		var got testModel
+		err := oteltag.UnmarshalBaggage(bag, &got)
+
+		var typeErr *oteltag.UnmarshalTypeError
+		if !errors.As(err, &typeErr) {
+			t.Fatalf("\ngot error %v\nwant %T", err, typeErr)
+		}
+
+		if typeErr.Field != "ValIntSlice" {
+			t.Errorf("\ngot field %q\nwant %q", typeErr.Field, "ValIntSlice")
+		}
+	})
+
+	t.Run("when not a pointer - should return an invalid unmarshal error", func(t *testing.T) {
+		err := oteltag.UnmarshalBaggage(baggage.Baggage{}, testModel{})
+
+		var invalidErr *oteltag.InvalidUnmarshalError
+		if !errors.As(err, &invalidErr) {
+			t.Fatalf("\ngot error %v\nwant %T", err, invalidErr)
+		}
+	})
+}
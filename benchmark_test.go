@@ -84,3 +84,49 @@ func BenchmarkSpanAttributes_Without(b *testing.B) {
 		}
 	})
 }
+
+func BenchmarkSpanAttributes(b *testing.B) {
+	m := testModel{
+		ValStr:          "a_string",
+		ValInt:          42,
+		ValInt64:        42000000000,
+		ValFloat64:      99.718281828,
+		ValBool:         true,
+		ValStrSlice:     []string{"a_string_1", "a_string_2", "a_string_3"},
+		ValIntSlice:     []int{1, 2, 3},
+		ValInt64Slice:   []int64{100000, 200000, 300000},
+		ValFloat64Slice: []float64{1.1, 2.2, 3.3},
+		ValBoolSlice:    []bool{true, false, true, false},
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_ = oteltag.SpanAttributes(m)
+		}
+	})
+}
+
+func BenchmarkBaggageMembers(b *testing.B) {
+	m := testModel{
+		ValStr:          "a_string",
+		ValInt:          42,
+		ValInt64:        42000000000,
+		ValFloat64:      99.718281828,
+		ValBool:         true,
+		ValStrSlice:     []string{"a_string_1", "a_string_2", "a_string_3"},
+		ValIntSlice:     []int{1, 2, 3},
+		ValInt64Slice:   []int64{100000, 200000, 300000},
+		ValFloat64Slice: []float64{1.1, 2.2, 3.3},
+		ValBoolSlice:    []bool{true, false, true, false},
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_ = oteltag.BaggageMembers(m)
+		}
+	})
+}
@@ -0,0 +1,144 @@
+package oteltag
+
+import (
+	"cmp"
+	"slices"
+
+	"go.opentelemetry.io/otel/baggage"
+)
+
+const (
+	// MaxBaggageBytes is the maximum size of a baggage header, as defined by the W3C Baggage specification.
+	MaxBaggageBytes = 8192
+
+	// MaxBaggageMembers is the maximum number of baggage members, as defined by the W3C Baggage specification.
+	MaxBaggageMembers = 180
+
+	// maxBaggageMemberBytes is the maximum size of a single encoded member.
+	maxBaggageMemberBytes = 4096
+)
+
+// A BaggageBuilder builds a [baggage.Baggage] from the members of any number of structs,
+// within the limits set with [WithBaggageLimits] (the W3C ones by default).
+//
+// Members are accounted for their encoded size. When over budget, the members with the lowest priority,
+// set with the priority tag option (e.g. `otel:"app.user.id,priority=10"`, 0 by default), are dropped first,
+// the last added ones first on equal priorities. Members larger than 4096 bytes are always dropped.
+type BaggageBuilder struct {
+	enc     *Encoder
+	entries []budgetEntry
+}
+
+// budgetEntry is a member added to a [BaggageBuilder].
+type budgetEntry struct {
+	member   baggage.Member
+	priority int
+	size     int // Encoded size.
+}
+
+// NewBaggageBuilder returns a [BaggageBuilder] using the default options and the W3C limits,
+// see [Encoder.NewBaggageBuilder] and [WithBaggageLimits] for other limits.
+func NewBaggageBuilder() *BaggageBuilder {
+	return defaultEncoder.NewBaggageBuilder()
+}
+
+// NewBaggageBuilder returns a [BaggageBuilder] using the options of e.
+func (e *Encoder) NewBaggageBuilder() *BaggageBuilder {
+	return &BaggageBuilder{enc: e}
+}
+
+// Add adds the members of v, a struct or a pointer to a struct, with the priorities of their fields.
+// It returns the same errors as [MarshalBaggage], in which case no member is added.
+func (b *BaggageBuilder) Add(v any) error {
+	structValue, err := marshalStructValue(v)
+	if err != nil || !structValue.IsValid() {
+		return err
+	}
+
+	var priorities []int
+	members, err := b.enc.rootToBaggageMembers(structValue, &priorities, false, true)
+	if err != nil {
+		return err
+	}
+
+	for i, member := range members {
+		b.AddMembers(priorities[i], member)
+	}
+
+	return nil
+}
+
+// AddMembers adds the provided members with the provided priority.
+// A member replaces the previously added member with the same key, like in [baggage.New].
+func (b *BaggageBuilder) AddMembers(priority int, members ...baggage.Member) {
+	for _, member := range members {
+		entry := budgetEntry{member: member, priority: priority, size: len(member.String())}
+
+		i := slices.IndexFunc(b.entries, func(e budgetEntry) bool { return e.member.Key() == member.Key() })
+		if i >= 0 {
+			b.entries[i] = entry
+			continue
+		}
+		b.entries = append(b.entries, entry)
+	}
+}
+
+// Build returns the baggage holding the added members within budget, and the dropped members
+// in the order they were dropped.
+func (b *BaggageBuilder) Build() (baggage.Baggage, []baggage.Member, error) {
+	kept := make([]bool, len(b.entries))
+	count, size := 0, 0
+	var dropped []baggage.Member
+	for i, entry := range b.entries {
+		if entry.size > maxBaggageMemberBytes {
+			dropped = append(dropped, entry.member)
+			continue
+		}
+
+		kept[i] = true
+		count++
+		size += entry.size
+	}
+
+	// Lowest priorities first, then last added first.
+	order := make([]int, len(b.entries))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(i, j int) int {
+		return cmp.Or(cmp.Compare(b.entries[i].priority, b.entries[j].priority), cmp.Compare(j, i))
+	})
+
+	for _, i := range order {
+		if count <= b.enc.maxBaggageMembers && headerSize(count, size) <= b.enc.maxBaggageBytes {
+			break
+		}
+		if !kept[i] {
+			continue
+		}
+
+		kept[i] = false
+		count--
+		size -= b.entries[i].size
+		dropped = append(dropped, b.entries[i].member)
+	}
+
+	members := make([]baggage.Member, 0, count)
+	for i, entry := range b.entries {
+		if kept[i] {
+			members = append(members, entry.member)
+		}
+	}
+
+	bag, err := baggage.New(members...)
+	return bag, dropped, err
+}
+
+// headerSize returns the size of a baggage header holding count members of the provided total size,
+// joined with commas.
+func headerSize(count, size int) int {
+	if count == 0 {
+		return 0
+	}
+	return size + count - 1
+}
@@ -0,0 +1,129 @@
+package oteltag_test
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/baggage"
+
+	oteltag "github.com/remychantenay/otel-tag"
+)
+
+type budgetTestModel struct {
+	TenantID string `otel:"tenant.id,priority=10"`
+	UserID   string `otel:"user.id,priority=5"`
+	Note     string `otel:"note"`
+	Locale   string `otel:"locale"`
+	Debug    string `otel:"debug,priority=-1"`
+}
+
+func memberKeys(members []baggage.Member) []string {
+	keys := make([]string, len(members))
+	for i, m := range members {
+		keys[i] = m.Key()
+	}
+	return keys
+}
+
+func TestBaggageBuilder(t *testing.T) {
+	m := budgetTestModel{TenantID: "t1", UserID: "u1", Note: "n", Locale: "fr", Debug: "on"}
+
+	t.Run("when within budget - should keep all members", func(t *testing.T) {
+		b := oteltag.NewBaggageBuilder()
+		if err := b.Add(m); err != nil {
+			t.Fatalf("\ngot error %v\nwant nil", err)
+		}
+
+		bag, dropped, err := b.Build()
+		if err != nil {
+			t.Fatalf("\ngot error %v\nwant nil", err)
+		}
+
+		if bag.Len() != 5 || len(dropped) != 0 {
+			t.Errorf("\ngot %v and dropped %v\nwant 5 members and none dropped", bag, dropped)
+		}
+	})
+
+	t.Run("when over the member limit - should drop the lowest priorities first", func(t *testing.T) {
+		b := oteltag.NewEncoder(oteltag.WithBaggageLimits(oteltag.MaxBaggageBytes, 2)).NewBaggageBuilder()
+		if err := b.Add(m); err != nil {
+			t.Fatalf("\ngot error %v\nwant nil", err)
+		}
+
+		bag, dropped, err := b.Build()
+		if err != nil {
+			t.Fatalf("\ngot error %v\nwant nil", err)
+		}
+
+		if want := []string{"debug", "locale", "note"}; !slices.Equal(memberKeys(dropped), want) {
+			t.Errorf("\ngot dropped %v\nwant %v", memberKeys(dropped), want)
+		}
+
+		if bag.Member("tenant.id").Value() != "t1" || bag.Member("user.id").Value() != "u1" {
+			t.Errorf("\ngot %v\nwant tenant.id and user.id", bag)
+		}
+	})
+
+	t.Run("when over the byte limit - should account for the encoded size", func(t *testing.T) {
+		// "tenant.id=t1,user.id=u1" is 23 bytes, an encoded space takes 3 bytes.
+		b := oteltag.NewEncoder(oteltag.WithBaggageLimits(23, oteltag.MaxBaggageMembers)).NewBaggageBuilder()
+		if err := b.Add(budgetTestModel{TenantID: "t1", UserID: "u1", Note: "  "}); err != nil {
+			t.Fatalf("\ngot error %v\nwant nil", err)
+		}
+
+		bag, dropped, err := b.Build()
+		if err != nil {
+			t.Fatalf("\ngot error %v\nwant nil", err)
+		}
+
+		if want := []string{"debug", "locale", "note"}; !slices.Equal(memberKeys(dropped), want) {
+			t.Errorf("\ngot dropped %v\nwant %v", memberKeys(dropped), want)
+		}
+
+		if got := bag.String(); len(got) != 23 {
+			t.Errorf("\ngot %q\nwant 23 bytes", got)
+		}
+	})
+
+	t.Run("when members added several times - should keep the last ones and drop oversized ones", func(t *testing.T) {
+		huge, _ := baggage.NewMemberRaw("huge", strings.Repeat("x", 4096))
+		tenant, _ := baggage.NewMemberRaw("tenant.id", "t2")
+
+		b := oteltag.NewBaggageBuilder()
+		b.AddMembers(100, huge)
+		if err := b.Add(m); err != nil {
+			t.Fatalf("\ngot error %v\nwant nil", err)
+		}
+		b.AddMembers(0, tenant)
+
+		bag, dropped, err := b.Build()
+		if err != nil {
+			t.Fatalf("\ngot error %v\nwant nil", err)
+		}
+
+		if !slices.Equal(memberKeys(dropped), []string{"huge"}) {
+			t.Errorf("\ngot dropped %v\nwant huge", memberKeys(dropped))
+		}
+
+		if bag.Len() != 5 || bag.Member("tenant.id").Value() != "t2" {
+			t.Errorf("\ngot %v\nwant 5 members and tenant.id=t2", bag)
+		}
+	})
+
+	t.Run("when invalid struct - should return an error and add nothing", func(t *testing.T) {
+		b := oteltag.NewBaggageBuilder()
+
+		var tagErr *oteltag.InvalidTagError
+		if err := b.Add(struct {
+			ID string `otel:"id,priority=high"`
+		}{ID: "1"}); !errors.As(err, &tagErr) {
+			t.Fatalf("\ngot error %v\nwant %T", err, tagErr)
+		}
+
+		if bag, _, _ := b.Build(); bag.Len() != 0 {
+			t.Errorf("\ngot %v\nwant empty baggage", bag)
+		}
+	})
+}
@@ -0,0 +1,104 @@
+package oteltag
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/remychantenay/otel-tag/internal"
+)
+
+// Converters is a concurrency-safe registry of custom converters, keyed by type.
+// Converters take precedence over the built-in conversions for fields of the exact registered type,
+// which comes in handy for types that cannot implement [fmt.Stringer] or [Attributer],
+// such as types from third-party modules.
+//
+// The package default registry, filled by [RegisterConverter] and [RegisterBaggageConverter], is always used.
+// A registry created with [NewConverters] and passed with [WithConverters] is consulted first.
+type Converters struct {
+	parent *Converters
+
+	mu      sync.RWMutex
+	span    map[reflect.Type]func(v reflect.Value) attribute.Value
+	baggage map[reflect.Type]func(v reflect.Value) string
+}
+
+// defaultConverters is the package default registry.
+var defaultConverters = &Converters{}
+
+// convertersGeneration is incremented on every registration, invalidating the plans cached by encoders.
+var convertersGeneration atomic.Uint64
+
+// NewConverters returns an empty registry, falling back to the package default registry.
+func NewConverters() *Converters {
+	return &Converters{parent: defaultConverters}
+}
+
+// RegisterConverter registers a span attribute converter for values of type T in the package default registry.
+func RegisterConverter[T any](fn func(T) attribute.Value) {
+	AddConverter(defaultConverters, fn)
+}
+
+// RegisterBaggageConverter registers a baggage member value converter for values of type T
+// in the package default registry.
+func RegisterBaggageConverter[T any](fn func(T) string) {
+	AddBaggageConverter(defaultConverters, fn)
+}
+
+// AddConverter registers a span attribute converter for values of type T in the provided registry.
+func AddConverter[T any](c *Converters, fn func(T) attribute.Value) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.span == nil {
+		c.span = make(map[reflect.Type]func(v reflect.Value) attribute.Value)
+	}
+	c.span[reflect.TypeFor[T]()] = func(v reflect.Value) attribute.Value {
+		return fn(v.Interface().(T))
+	}
+	convertersGeneration.Add(1)
+}
+
+// AddBaggageConverter registers a baggage member value converter for values of type T in the provided registry.
+func AddBaggageConverter[T any](c *Converters, fn func(T) string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.baggage == nil {
+		c.baggage = make(map[reflect.Type]func(v reflect.Value) string)
+	}
+	c.baggage[reflect.TypeFor[T]()] = func(v reflect.Value) string {
+		return fn(v.Interface().(T))
+	}
+	convertersGeneration.Add(1)
+}
+
+// SpanConverter implements [internal.Converters].
+func (c *Converters) SpanConverter(t reflect.Type) func(v reflect.Value) attribute.Value {
+	c.mu.RLock()
+	fn := c.span[t]
+	c.mu.RUnlock()
+
+	if fn == nil && c.parent != nil {
+		return c.parent.SpanConverter(t)
+	}
+
+	return fn
+}
+
+// BaggageConverter implements [internal.Converters].
+func (c *Converters) BaggageConverter(t reflect.Type) func(v reflect.Value) string {
+	c.mu.RLock()
+	fn := c.baggage[t]
+	c.mu.RUnlock()
+
+	if fn == nil && c.parent != nil {
+		return c.parent.BaggageConverter(t)
+	}
+
+	return fn
+}
+
+var _ internal.Converters = (*Converters)(nil)
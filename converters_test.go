@@ -0,0 +1,110 @@
+package oteltag_test
+
+import (
+	"math/big"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	oteltag "github.com/remychantenay/otel-tag"
+)
+
+// testMoney mimics a third-party type that cannot be given methods.
+type testMoney struct {
+	units int64
+	cents int64
+}
+
+func TestConverters(t *testing.T) {
+	t.Run("when converters registered - should use them before the built-in conversions", func(t *testing.T) {
+		type order struct {
+			Total *big.Int `otel:"order.total"`
+			Count int      `otel:"order.count"`
+		}
+
+		oteltag.RegisterConverter(func(v *big.Int) attribute.Value {
+			return attribute.StringValue(v.String())
+		})
+		oteltag.RegisterBaggageConverter(func(v *big.Int) string {
+			return v.String()
+		})
+
+		m := order{Total: big.NewInt(1234), Count: 1}
+
+		want := []attribute.KeyValue{
+			attribute.String("order.total", "1234"),
+			attribute.Int("order.count", 1),
+		}
+
+		got := oteltag.SpanAttributes(m)
+		if !slices.Equal(got, want) {
+			t.Errorf("\ngot %v\nwant %v", got, want)
+		}
+
+		members := oteltag.BaggageMembers(m)
+		if len(members) != 2 || members[0].Value() != "1234" {
+			t.Errorf("\ngot %v\nwant order.total=1234 and order.count=1", members)
+		}
+	})
+
+	t.Run("when converters registered in a registry - should only use them with that registry", func(t *testing.T) {
+		type payment struct {
+			Amount testMoney `otel:"payment.amount"`
+		}
+
+		m := payment{Amount: testMoney{units: 12, cents: 50}}
+
+		if got := oteltag.SpanAttributes(m); len(got) != 0 {
+			t.Fatalf("\ngot %v before registration\nwant no attributes", got)
+		}
+
+		converters := oteltag.NewConverters()
+		oteltag.AddConverter(converters, func(v testMoney) attribute.Value {
+			return attribute.Float64Value(float64(v.units) + float64(v.cents)/100)
+		})
+
+		want := []attribute.KeyValue{attribute.Float64("payment.amount", 12.5)}
+
+		got := oteltag.NewEncoder(oteltag.WithConverters(converters)).SpanAttributes(m)
+		if !slices.Equal(got, want) {
+			t.Errorf("\ngot %v\nwant %v", got, want)
+		}
+
+		if got := oteltag.SpanAttributes(m); len(got) != 0 {
+			t.Errorf("\ngot %v without the registry\nwant no attributes", got)
+		}
+	})
+
+	t.Run("when registering concurrently - should not race", func(t *testing.T) {
+		type counter struct {
+			Value testMoney `otel:"counter.value"`
+		}
+
+		converters := oteltag.NewConverters()
+		enc := oteltag.NewEncoder(oteltag.WithConverters(converters))
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				oteltag.AddBaggageConverter(converters, func(v testMoney) string {
+					return strconv.FormatInt(v.units, 10)
+				})
+			}()
+			go func() {
+				defer wg.Done()
+				_ = enc.BaggageMembers(counter{})
+			}()
+		}
+		wg.Wait()
+
+		members := enc.BaggageMembers(counter{Value: testMoney{units: 3}})
+		if len(members) != 1 || members[0].Value() != "3" {
+			t.Errorf("\ngot %v\nwant counter.value=3", members)
+		}
+	})
+}
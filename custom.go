@@ -0,0 +1,59 @@
+package oteltag
+
+import (
+	"reflect"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+
+	"github.com/remychantenay/otel-tag/internal"
+)
+
+// Attributer is implemented by types providing their own span attributes.
+//
+// The attributes replace the tag-based ones of the value, unless the field holding it is tagged with
+// the merge option (e.g. `otel:",merge"`), in which case both are extracted.
+// A top-level value implementing Attributer replaces its tag-based attributes, unless the encoder is created
+// with [WithRootMerge]. Implementations must not extract their own value with this package, which would
+// recurse endlessly.
+type Attributer interface {
+	OtelAttributes() []attribute.KeyValue
+}
+
+// Baggager is implemented by types providing their own baggage members, see [Attributer].
+type Baggager interface {
+	OtelBaggage() []baggage.Member
+}
+
+// BaggagePropertier is implemented by types providing the properties of the baggage member of the field
+// holding them, appended to the ones of the prop tag options (e.g. `otel:"app.user.email,prop=sensitive"`).
+type BaggagePropertier interface {
+	OtelBaggageProperties() []baggage.Property
+}
+
+var (
+	attributerType = reflect.TypeFor[Attributer]()
+	baggagerType   = reflect.TypeFor[Baggager]()
+)
+
+// customAttributes returns the attributes provided by a value implementing [Attributer]
+// on a value or pointer receiver.
+func customAttributes(v reflect.Value) []attribute.KeyValue {
+	a, ok := internal.MethodReceiver[Attributer](v)
+	if !ok {
+		return nil
+	}
+
+	return a.OtelAttributes()
+}
+
+// customBaggageMembers returns the members provided by a value implementing [Baggager]
+// on a value or pointer receiver.
+func customBaggageMembers(v reflect.Value) []baggage.Member {
+	b, ok := internal.MethodReceiver[Baggager](v)
+	if !ok {
+		return nil
+	}
+
+	return b.OtelBaggage()
+}
@@ -0,0 +1,81 @@
+package oteltag
+
+import (
+	"reflect"
+	"slices"
+)
+
+// decodeFieldFunc sets a leaf field from the decoded source and returns whether it was set.
+type decodeFieldFunc func(field *fieldPlan, fieldValue reflect.Value) (bool, error)
+
+// decodeStruct sets the fields of a struct located depth levels below the top-level struct, whose keys are
+// prefixed with prefix, using decodeField and returns whether at least one field was set.
+// Nil pointers to structs are allocated only if at least one of their fields was set.
+// allocating holds the types of the nil struct pointers being allocated, preventing endless recursion.
+//
+// If missing is not nil, decodeField may append the keys of the fields it could not set to it: the ones of
+// nested structs left unallocated, or tagged with omitzero, are dropped if none of their fields was set.
+func (e *Encoder) decodeStruct(structValue reflect.Value, prefix string, depth int, allocating []reflect.Type, missing *[]string, decodeField decodeFieldFunc) (bool, error) {
+	plan := e.planFor(structValue.Type(), prefix)
+
+	var decoded bool
+	for i := range plan.fields {
+		field := &plan.fields[i]
+		fieldValue := fieldValue(structValue, field.index)
+		if !fieldValue.CanSet() || e.tooDeep(field, depth) {
+			continue
+		}
+
+		if !field.node {
+			ok, err := decodeField(field, fieldValue)
+			if err != nil {
+				return false, err
+			}
+			decoded = decoded || ok
+			continue
+		}
+
+		if field.elem == nil {
+			continue
+		}
+
+		mark := 0
+		if missing != nil {
+			mark = len(*missing)
+		}
+
+		if !field.pointer {
+			ok, err := e.decodeStruct(fieldValue, field.prefix, depth+field.depth+1, allocating, missing, decodeField)
+			if err != nil {
+				return false, withParentField(err, field.name)
+			}
+			if !ok && field.isZero != nil && missing != nil {
+				*missing = (*missing)[:mark]
+			}
+			decoded = decoded || ok
+			continue
+		}
+
+		elemValue, elemAllocating := fieldValue, allocating
+		if fieldValue.IsNil() {
+			if slices.Contains(allocating, field.elem) {
+				continue
+			}
+			elemValue, elemAllocating = reflect.New(field.elem), append(allocating, field.elem)
+		}
+
+		ok, err := e.decodeStruct(elemValue.Elem(), field.prefix, depth+field.depth+1, elemAllocating, missing, decodeField)
+		if err != nil {
+			return false, withParentField(err, field.name)
+		}
+		if ok && fieldValue.IsNil() {
+			fieldValue.Set(elemValue)
+		}
+		if !ok && (fieldValue.IsNil() || field.isZero != nil) && missing != nil {
+			*missing = (*missing)[:mark]
+		}
+		decoded = decoded || ok
+	}
+
+	return decoded, nil
+}
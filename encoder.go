@@ -0,0 +1,266 @@
+package oteltag
+
+import (
+	"context"
+	"reflect"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/remychantenay/otel-tag/internal"
+)
+
+// Encoder extracts span attributes and baggage members from structs, and decodes them back,
+// according to its options. An Encoder is safe for concurrent use and caches the compiled plan
+// of every struct type it sees, it should therefore be created once and reused.
+//
+// The package-level functions use a default Encoder without options.
+type Encoder struct {
+	tagName          string
+	keyPrefix        string
+	keyNaming        KeyNaming
+	sliceSeparator   string
+	omitEmpty        bool
+	maxDepth         int
+	unexportedFields bool
+	rootMerge        bool
+	strictKeys       bool
+	converters       *Converters
+
+	noRedaction bool
+	hashKeyID   string
+	hasher      internal.Hasher
+
+	maxLength        int
+	truncationMarker TruncationMarker
+
+	maxBaggageBytes   int
+	maxBaggageMembers int
+	mergePolicy       MergePolicy
+
+	// plans caches the compiled [typePlan] of every struct type seen so far.
+	plans sync.Map // map[planKey]*typePlan
+}
+
+// defaultHashSize is the number of bytes of the hashes kept by default.
+const defaultHashSize = 16
+
+// defaultEncoder is used by the package-level functions.
+var defaultEncoder = NewEncoder()
+
+// NewEncoder returns an [Encoder] configured with the provided options.
+func NewEncoder(opts ...Option) *Encoder {
+	e := &Encoder{
+		tagName:           internal.DefaultTagName,
+		sliceSeparator:    internal.DefaultSliceSeparator,
+		maxDepth:          -1,
+		converters:        defaultConverters,
+		hasher:            internal.Hasher{Size: defaultHashSize},
+		maxBaggageBytes:   MaxBaggageBytes,
+		maxBaggageMembers: MaxBaggageMembers,
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	return e
+}
+
+// ValidateTags reports the first tag holding an unknown or malformed option as an [*InvalidTagError],
+// in the struct type of v (a struct or a pointer to a struct, possibly nil) and all the structs it nests.
+// Unlike the Marshal functions, it also checks the structs behind nil pointers,
+// which makes it suitable for tests or init-time checks.
+func ValidateTags(v any) error {
+	return defaultEncoder.ValidateTags(v)
+}
+
+// SpanAttributes is like the package-level [SpanAttributes] function, using the options of e.
+func (e *Encoder) SpanAttributes(v any) []attribute.KeyValue {
+	structValue, ok := structValue(reflect.ValueOf(v))
+	if !ok {
+		return nil
+	}
+
+	attrs, _ := e.rootToAttributes(structValue, false)
+	return attrs
+}
+
+// MarshalAttributes is like the package-level [MarshalAttributes] function, using the options of e.
+func (e *Encoder) MarshalAttributes(v any) ([]attribute.KeyValue, error) {
+	structValue, err := marshalStructValue(v)
+	if err != nil || !structValue.IsValid() {
+		return nil, err
+	}
+
+	return e.rootToAttributes(structValue, true)
+}
+
+// BaggageMembers is like the package-level [BaggageMembers] function, using the options of e.
+func (e *Encoder) BaggageMembers(v any) []baggage.Member {
+	structValue, ok := structValue(reflect.ValueOf(v))
+	if !ok {
+		return nil
+	}
+
+	members, _ := e.rootToBaggageMembers(structValue, nil, false, false)
+	return members
+}
+
+// MarshalBaggage is like the package-level [MarshalBaggage] function, using the options of e.
+func (e *Encoder) MarshalBaggage(v any) ([]baggage.Member, error) {
+	structValue, err := marshalStructValue(v)
+	if err != nil || !structValue.IsValid() {
+		return nil, err
+	}
+
+	return e.rootToBaggageMembers(structValue, nil, false, true)
+}
+
+// ContextWithBaggage is like the package-level [ContextWithBaggage] function, using the options of e.
+// The policy set with [WithMergePolicy] applies unless another one is provided.
+func (e *Encoder) ContextWithBaggage(ctx context.Context, v any, policy ...MergePolicy) (context.Context, error) {
+	members, err := e.MarshalBaggage(v)
+	if err != nil {
+		return ctx, err
+	}
+	if len(members) == 0 {
+		return ctx, nil
+	}
+
+	mergePolicy := e.mergePolicy
+	if len(policy) > 0 {
+		mergePolicy = policy[len(policy)-1]
+	}
+
+	bag, err := mergeBaggage(baggage.FromContext(ctx), members, mergePolicy)
+	if err != nil {
+		return ctx, err
+	}
+
+	return baggage.ContextWithBaggage(ctx, bag), nil
+}
+
+// Start is like the package-level [Start] function, using the options of e.
+func (e *Encoder) Start(ctx context.Context, tracer trace.Tracer, name string, v any, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	ctx, span := tracer.Start(ctx, name, opts...)
+	if !span.IsRecording() {
+		return ctx, span
+	}
+
+	structValue, ok := structValue(reflect.ValueOf(v))
+	if !ok {
+		return ctx, span
+	}
+
+	if attrs, _ := e.rootToAttributes(structValue, false); len(attrs) > 0 {
+		span.SetAttributes(attrs...)
+	}
+
+	members, _ := e.rootToBaggageMembers(structValue, nil, true, false)
+	if len(members) == 0 {
+		return ctx, span
+	}
+
+	bag := baggage.FromContext(ctx)
+	for _, member := range members {
+		if merged, err := mergeBaggage(bag, []baggage.Member{member}, e.mergePolicy); err == nil {
+			bag = merged
+		}
+	}
+
+	return baggage.ContextWithBaggage(ctx, bag), span
+}
+
+// UnmarshalAttributes is like the package-level [UnmarshalAttributes] function, using the options of e.
+func (e *Encoder) UnmarshalAttributes(attrs []attribute.KeyValue, v any) error {
+	structValue, err := unmarshalStructValue(v)
+	if err != nil {
+		return err
+	}
+
+	return e.decodeAttributes(attrs, structValue)
+}
+
+// UnmarshalSpan is like the package-level [UnmarshalSpan] function, using the options of e.
+func (e *Encoder) UnmarshalSpan(span sdktrace.ReadOnlySpan, v any) error {
+	return e.UnmarshalAttributes(span.Attributes(), v)
+}
+
+// UnmarshalBaggage is like the package-level [UnmarshalBaggage] function, using the options of e.
+func (e *Encoder) UnmarshalBaggage(bag baggage.Baggage, v any) error {
+	structValue, err := unmarshalStructValue(v)
+	if err != nil {
+		return err
+	}
+
+	_, err = e.decodeStruct(structValue, "", 0, nil, nil, baggageFieldDecoder(bag))
+	return err
+}
+
+// ValidateTags is like the package-level [ValidateTags] function, using the options of e.
+func (e *Encoder) ValidateTags(v any) error {
+	t := reflect.TypeOf(v)
+	if t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return &UnsupportedTypeError{Type: reflect.TypeOf(v)}
+	}
+
+	return e.validateTags(t, "", nil)
+}
+
+// validateTags returns the first invalid tag of the provided struct type and of the structs it points to.
+// visited holds the struct types already validated, preventing endless recursion: tags do not depend on prefixes.
+func (e *Encoder) validateTags(t reflect.Type, prefix string, visited map[reflect.Type]struct{}) error {
+	if _, ok := visited[t]; ok {
+		return nil
+	}
+	if visited == nil {
+		visited = make(map[reflect.Type]struct{})
+	}
+	visited[t] = struct{}{}
+
+	plan := e.planFor(t, prefix)
+	if err := plan.invalidTag(); err != nil {
+		return err
+	}
+
+	for i := range plan.fields {
+		field := &plan.fields[i]
+		if !field.node || field.elem == nil {
+			continue
+		}
+
+		if err := e.validateTags(field.elem, field.prefix, visited); err != nil {
+			return withParentField(err, field.name)
+		}
+	}
+
+	return nil
+}
+
+// planFor returns the [typePlan] of the provided struct type under the provided key prefix,
+// building it if needed.
+func (e *Encoder) planFor(t reflect.Type, prefix string) *typePlan {
+	key := planKey{typ: t, prefix: prefix}
+	generation := convertersGeneration.Load()
+	if p, ok := e.plans.Load(key); ok && p.(*typePlan).generation == generation {
+		return p.(*typePlan)
+	}
+
+	p := e.buildPlan(t, prefix)
+	p.generation = generation
+	e.plans.Store(key, p)
+
+	return p
+}
+
+// tooDeep reports whether the field of a struct located depth levels below the top-level struct
+// is beyond the maximum depth.
+func (e *Encoder) tooDeep(field *fieldPlan, depth int) bool {
+	return e.maxDepth >= 0 && depth+field.depth > e.maxDepth
+}
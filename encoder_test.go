@@ -0,0 +1,595 @@
+package oteltag_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+
+	oteltag "github.com/remychantenay/otel-tag"
+)
+
+type testDepthLevel2 struct {
+	Name string `otel:"level2.name"`
+}
+
+type testDepthLevel1 struct {
+	Name   string `otel:"level1.name"`
+	Level2 testDepthLevel2
+	Next   *testDepthLevel2
+}
+
+type depthTestModel struct {
+	Name   string `otel:"level0.name"`
+	Level1 testDepthLevel1
+}
+
+func TestEncoder(t *testing.T) {
+	t.Run("when no options - should behave like the package-level functions", func(t *testing.T) {
+		m := testModel{ValStr: "str", ValInt: 1, ValStrSlice: []string{"a", "b"}}
+		enc := oteltag.NewEncoder()
+
+		if got, want := enc.SpanAttributes(m), oteltag.SpanAttributes(m); !slices.Equal(got, want) {
+			t.Errorf("\ngot %v\nwant %v", got, want)
+		}
+
+		got, want := enc.BaggageMembers(m), oteltag.BaggageMembers(m)
+		if len(got) != len(want) {
+			t.Fatalf("\ngot %v\nwant %v", got, want)
+		}
+		for i := range got {
+			if got[i].String() != want[i].String() {
+				t.Errorf("\ngot %v\nwant %v", got[i], want[i])
+			}
+		}
+	})
+
+	t.Run("when tag name provided - should read the keys from that tag", func(t *testing.T) {
+		type model struct {
+			ID   string `otel:"otel.id" trace:"trace.id"`
+			Name string `otel:"otel.name"`
+		}
+
+		enc := oteltag.NewEncoder(oteltag.WithTagName("trace"))
+
+		want := []attribute.KeyValue{attribute.String("trace.id", "42")}
+
+		got := enc.SpanAttributes(model{ID: "42", Name: "name"})
+		if !slices.Equal(got, want) {
+			t.Errorf("\ngot %v\nwant %v", got, want)
+		}
+	})
+
+	t.Run("when key prefix provided - should prefix all keys and decode them back", func(t *testing.T) {
+		type model struct {
+			ID      string `otel:"user.id"`
+			Address struct {
+				City string `otel:"address.city"`
+			}
+		}
+
+		m := model{ID: "42"}
+		m.Address.City = "Paris"
+		enc := oteltag.NewEncoder(oteltag.WithKeyPrefix("app."))
+
+		want := []attribute.KeyValue{
+			attribute.String("app.user.id", "42"),
+			attribute.String("app.address.city", "Paris"),
+		}
+
+		got := enc.SpanAttributes(m)
+		if !slices.Equal(got, want) {
+			t.Fatalf("\ngot %v\nwant %v", got, want)
+		}
+
+		var decoded model
+		if err := enc.UnmarshalAttributes(got, &decoded); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if decoded != m {
+			t.Errorf("\ngot %+v\nwant %+v", decoded, m)
+		}
+	})
+
+	t.Run("when slice separator provided - should join and split baggage slices with it", func(t *testing.T) {
+		type model struct {
+			Tags []string `otel:"tags"`
+		}
+
+		m := model{Tags: []string{"a", "b", "c"}}
+		enc := oteltag.NewEncoder(oteltag.WithSliceSeparator("|"))
+
+		members := enc.BaggageMembers(m)
+		if len(members) != 1 || members[0].Value() != "a|b|c" {
+			t.Fatalf("\ngot %v\nwant tags=a|b|c", members)
+		}
+
+		bag, err := baggage.New(members...)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		var decoded model
+		if err := enc.UnmarshalBaggage(bag, &decoded); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !slices.Equal(decoded.Tags, m.Tags) {
+			t.Errorf("\ngot %v\nwant %v", decoded.Tags, m.Tags)
+		}
+	})
+
+	t.Run("when omitempty by default - should skip all zero values", func(t *testing.T) {
+		type model struct {
+			ID    string `otel:"id"`
+			Count int    `otel:"count"`
+		}
+
+		enc := oteltag.NewEncoder(oteltag.WithOmitEmpty())
+
+		want := []attribute.KeyValue{attribute.String("id", "42")}
+
+		got := enc.SpanAttributes(model{ID: "42"})
+		if !slices.Equal(got, want) {
+			t.Errorf("\ngot %v\nwant %v", got, want)
+		}
+
+		if members := enc.BaggageMembers(model{ID: "42"}); len(members) != 1 {
+			t.Errorf("\ngot %v\nwant id=42", members)
+		}
+	})
+
+	t.Run("when max depth provided - should ignore the fields of deeper structs", func(t *testing.T) {
+		m := depthTestModel{
+			Name: "0",
+			Level1: testDepthLevel1{
+				Name:   "1",
+				Level2: testDepthLevel2{Name: "2"},
+				Next:   &testDepthLevel2{Name: "2"},
+			},
+		}
+
+		tests := []struct {
+			depth int
+			want  []attribute.KeyValue
+		}{
+			{
+				depth: 0,
+				want:  []attribute.KeyValue{attribute.String("level0.name", "0")},
+			},
+			{
+				depth: 1,
+				want: []attribute.KeyValue{
+					attribute.String("level0.name", "0"),
+					attribute.String("level1.name", "1"),
+				},
+			},
+			{
+				depth: -1,
+				want: []attribute.KeyValue{
+					attribute.String("level0.name", "0"),
+					attribute.String("level1.name", "1"),
+					attribute.String("level2.name", "2"),
+					attribute.String("level2.name", "2"),
+				},
+			},
+		}
+
+		for _, tt := range tests {
+			enc := oteltag.NewEncoder(oteltag.WithMaxDepth(tt.depth))
+
+			got := enc.SpanAttributes(m)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("depth %d\ngot %v\nwant %v", tt.depth, got, tt.want)
+			}
+
+			if members := enc.BaggageMembers(m); len(members) != len(tt.want) {
+				t.Errorf("depth %d\ngot %v\nwant %d members", tt.depth, members, len(tt.want))
+			}
+		}
+	})
+
+	t.Run("when auto keys - should derive the keys of untagged exported fields", func(t *testing.T) {
+		type details struct {
+			HomePage string
+		}
+
+		type model struct {
+			UserID     string
+			HTTPStatus int       `otel:",omitempty"`
+			CreatedAt  time.Time `json:"created"`
+			Nickname   string    `otel:"user.nick"`
+			Ignored    string    `otel:"-"`
+			Hidden     string    `json:"-"`
+			Details    details
+			internal   string
+		}
+
+		m := model{
+			UserID:    "42",
+			CreatedAt: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+			Nickname:  "jd",
+			Ignored:   "ignored",
+			Hidden:    "hidden",
+			Details:   details{HomePage: "https://example.com"},
+			internal:  "internal",
+		}
+
+		tests := []struct {
+			naming oteltag.KeyNaming
+			want   []attribute.KeyValue
+		}{
+			{
+				naming: oteltag.SnakeCase,
+				want: []attribute.KeyValue{
+					attribute.String("user_id", "42"),
+					attribute.String("created_at", "2024-03-01T12:00:00Z"),
+					attribute.String("user.nick", "jd"),
+					attribute.String("hidden", "hidden"),
+					attribute.String("home_page", "https://example.com"),
+				},
+			},
+			{
+				naming: oteltag.DotCase,
+				want: []attribute.KeyValue{
+					attribute.String("user.id", "42"),
+					attribute.String("created.at", "2024-03-01T12:00:00Z"),
+					attribute.String("user.nick", "jd"),
+					attribute.String("hidden", "hidden"),
+					attribute.String("home.page", "https://example.com"),
+				},
+			},
+			{
+				naming: oteltag.JSONName(nil),
+				want: []attribute.KeyValue{
+					attribute.String("user_id", "42"),
+					attribute.String("created", "2024-03-01T12:00:00Z"),
+					attribute.String("user.nick", "jd"),
+					attribute.String("home_page", "https://example.com"),
+				},
+			},
+		}
+
+		for _, tt := range tests {
+			enc := oteltag.NewEncoder(oteltag.WithAutoKeys(tt.naming))
+
+			got := enc.SpanAttributes(m)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("\ngot %v\nwant %v", got, tt.want)
+			}
+		}
+
+		if got := oteltag.SpanAttributes(m); len(got) != 1 {
+			t.Errorf("\ngot %v without auto keys\nwant user.nick only", got)
+		}
+	})
+
+	t.Run("when redaction disabled - should extract redacted and masked values as is", func(t *testing.T) {
+		m := newRedactTestModel()
+		enc := oteltag.NewEncoder(oteltag.WithoutRedaction(), oteltag.WithStrictKeys())
+
+		want := []attribute.KeyValue{
+			attribute.String("user.email", m.Email),
+			attribute.Int("user.age", m.Age),
+			attribute.String("user.card", m.Card),
+			attribute.String("user.phone", m.Phone),
+			attribute.String("user.pin", m.PIN),
+			attribute.StringSlice("user.tokens", m.Tokens),
+			attribute.String("user.username", m.Username),
+		}
+
+		got := enc.SpanAttributes(m)
+		if !slices.Equal(got, want) {
+			t.Errorf("\ngot %v\nwant %v", got, want)
+		}
+
+		var decoded redactTestModel
+		if err := enc.UnmarshalAttributes(got, &decoded); err != nil {
+			t.Fatalf("\ngot error %v\nwant nil", err)
+		}
+		if decoded.Email != m.Email || decoded.Card != m.Card {
+			t.Errorf("\ngot %+v\nwant %+v", decoded, m)
+		}
+	})
+
+	t.Run("when hash key provided - should extract keyed hashes and the key id", func(t *testing.T) {
+		type model struct {
+			UserID string   `otel:"user.id,hash"`
+			Age    int      `otel:"user.age,hash,omitempty"`
+			Roles  []string `otel:"user.roles,hash"`
+			Name   string   `otel:"user.name"`
+		}
+
+		secret := []byte("s3cr3t")
+		sum := func(v string) []byte {
+			mac := hmac.New(sha256.New, secret)
+			mac.Write([]byte(v))
+			return mac.Sum(nil)
+		}
+
+		m := model{UserID: "42", Roles: []string{"admin"}, Name: "john_doe"}
+
+		enc := oteltag.NewEncoder(oteltag.WithHashKey("k2", secret))
+		want := []attribute.KeyValue{
+			attribute.String("user.id", hex.EncodeToString(sum("42")[:16])),
+			attribute.String("user.id.key_id", "k2"),
+			attribute.StringSlice("user.roles", []string{hex.EncodeToString(sum("admin")[:16])}),
+			attribute.String("user.roles.key_id", "k2"),
+			attribute.String("user.name", "john_doe"),
+		}
+
+		got := enc.SpanAttributes(m)
+		if !slices.Equal(got, want) {
+			t.Errorf("\ngot %v\nwant %v", got, want)
+		}
+
+		enc = oteltag.NewEncoder(oteltag.WithHashKey("", secret), oteltag.WithHashFormat(8, oteltag.HashBase64))
+		wantValue := base64.RawURLEncoding.EncodeToString(sum("42")[:8])
+
+		members := enc.BaggageMembers(m)
+		if len(members) != 3 || members[0].Key() != "user.id" || members[0].Value() != wantValue {
+			t.Errorf("\ngot %v\nwant user.id=%s, user.roles and user.name", members, wantValue)
+		}
+	})
+
+	t.Run("when hashed slices - should hash each element like span attributes", func(t *testing.T) {
+		type model struct {
+			IDs []string `otel:"user.ids,hash"`
+		}
+
+		m := model{IDs: []string{"42", "43"}}
+		enc := oteltag.NewEncoder(oteltag.WithHashKey("", []byte("s3cr3t")))
+
+		attrs := enc.SpanAttributes(m)
+		if len(attrs) != 1 {
+			t.Fatalf("\ngot %v\nwant user.ids only", attrs)
+		}
+		want := strings.Join(attrs[0].Value.AsStringSlice(), ",")
+
+		members := enc.BaggageMembers(m)
+		if len(members) != 1 || members[0].Value() != want {
+			t.Errorf("\ngot %v\nwant user.ids=%s", members, want)
+		}
+	})
+
+	t.Run("when hashed and masked floats - should redact them like span attributes", func(t *testing.T) {
+		type model struct {
+			Ratio  float64 `otel:"user.ratio,hash"`
+			Weight float64 `otel:"user.weight,mask=first3"`
+		}
+
+		m := model{Ratio: 0.00001, Weight: 0.00001}
+		enc := oteltag.NewEncoder(oteltag.WithHashKey("", []byte("s3cr3t")))
+
+		attrs := enc.SpanAttributes(m)
+		if len(attrs) != 2 {
+			t.Fatalf("\ngot %v\nwant user.ratio and user.weight", attrs)
+		}
+
+		members := enc.BaggageMembers(m)
+		if len(members) != 2 {
+			t.Fatalf("\ngot %v\nwant user.ratio and user.weight", members)
+		}
+
+		for i := range attrs {
+			if got, want := members[i].Value(), attrs[i].Value.AsString(); got != want {
+				t.Errorf("\ngot %s=%s\nwant %s", members[i].Key(), got, want)
+			}
+		}
+
+		if got, want := attrs[1].Value.AsString(), "0.0****"; got != want {
+			t.Errorf("\ngot %s\nwant %s", got, want)
+		}
+	})
+
+	t.Run("when hash key missing - should never extract hashed fields", func(t *testing.T) {
+		type model struct {
+			UserID string `otel:"user.id,hash"`
+			Name   string `otel:"user.name"`
+		}
+
+		m := model{UserID: "42", Name: "john_doe"}
+
+		want := []attribute.KeyValue{attribute.String("user.name", "john_doe")}
+
+		got := oteltag.SpanAttributes(m)
+		if !slices.Equal(got, want) {
+			t.Errorf("\ngot %v\nwant %v", got, want)
+		}
+
+		_, err := oteltag.MarshalBaggage(m)
+		if !errors.Is(err, oteltag.ErrMissingHashKey) {
+			t.Errorf("\ngot error %v\nwant %v", err, oteltag.ErrMissingHashKey)
+		}
+	})
+
+	t.Run("when default max length and truncation marker - should truncate all values and mark them", func(t *testing.T) {
+		m := newTruncateTestModel()
+
+		enc := oteltag.NewEncoder(oteltag.WithMaxLength(6), oteltag.WithTruncationMarker(oteltag.TruncationFlag), oteltag.WithStrictKeys())
+		want := []attribute.KeyValue{
+			attribute.String("order.description", "crème"),
+			attribute.Bool("order.description.truncated", true),
+			attribute.String("order.note", "short"),
+			attribute.StringSlice("order.tags", []string{"a", "b"}),
+			attribute.Bool("order.tags.truncated", true),
+			attribute.IntSlice("order.ids", []int{1, 2}),
+			attribute.String("order.title", "a long"),
+			attribute.Bool("order.title.truncated", true),
+		}
+
+		got := enc.SpanAttributes(m)
+		if !slices.Equal(got, want) {
+			t.Errorf("\ngot %v\nwant %v", got, want)
+		}
+
+		if err := enc.UnmarshalAttributes(got, &truncateTestModel{}); err != nil {
+			t.Errorf("\ngot error %v\nwant nil", err)
+		}
+
+		enc = oteltag.NewEncoder(oteltag.WithTruncationMarker(oteltag.TruncationOriginalLength))
+		wantMembers := []string{
+			"order.description=crème",
+			"order.description.original_length=12",
+			"order.note=short",
+			"order.tags=a,b",
+			"order.tags.original_length=3",
+			"order.ids=1,2",
+			"order.title=a long title",
+		}
+
+		members := enc.BaggageMembers(m)
+
+		gotMembers := make([]string, len(members))
+		for i, m := range members {
+			gotMembers[i] = m.Key() + "=" + m.Value()
+		}
+
+		if !slices.Equal(gotMembers, wantMembers) {
+			t.Errorf("\ngot %v\nwant %v", gotMembers, wantMembers)
+		}
+	})
+
+	t.Run("when default max length and numeric or time fields - should not truncate them", func(t *testing.T) {
+		type model struct {
+			At      time.Time     `otel:"at,format=unixmilli"`
+			Elapsed time.Duration `otel:"elapsed"`
+			Count   int64         `otel:"count"`
+			Code    int           `otel:"code,max=2"`
+			Name    string        `otel:"name"`
+		}
+
+		m := model{
+			At:      time.UnixMilli(1700000000000),
+			Elapsed: 1234567890,
+			Count:   1234567890,
+			Code:    12345,
+			Name:    "john_doe",
+		}
+		enc := oteltag.NewEncoder(oteltag.WithMaxLength(4), oteltag.WithTruncationMarker(oteltag.TruncationFlag))
+
+		want := []string{
+			"at=1700000000000",
+			"elapsed=1234567890",
+			"count=1234567890",
+			"code=12345",
+			"name=john",
+			"name.truncated=true",
+		}
+
+		members := enc.BaggageMembers(m)
+		got := make([]string, len(members))
+		for i, m := range members {
+			got[i] = m.Key() + "=" + m.Value()
+		}
+
+		if !slices.Equal(got, want) {
+			t.Errorf("\ngot %v\nwant %v", got, want)
+		}
+
+		wantAttrs := []attribute.KeyValue{
+			attribute.Int64("at", 1700000000000),
+			attribute.Int64("elapsed", 1234567890),
+			attribute.Int64("count", 1234567890),
+			attribute.Int("code", 12345),
+			attribute.String("name", "john"),
+			attribute.Bool("name.truncated", true),
+		}
+
+		if gotAttrs := enc.SpanAttributes(m); !slices.Equal(gotAttrs, wantAttrs) {
+			t.Errorf("\ngot %v\nwant %v", gotAttrs, wantAttrs)
+		}
+	})
+
+	t.Run("when converters provided - should use them", func(t *testing.T) {
+		type payment struct {
+			Amount testMoney `otel:"payment.amount"`
+		}
+
+		converters := oteltag.NewConverters()
+		oteltag.AddConverter(converters, func(v testMoney) attribute.Value {
+			return attribute.Int64Value(v.units*100 + v.cents)
+		})
+		enc := oteltag.NewEncoder(oteltag.WithConverters(converters))
+
+		want := []attribute.KeyValue{attribute.Int64("payment.amount", 1250)}
+
+		got := enc.SpanAttributes(payment{Amount: testMoney{units: 12, cents: 50}})
+		if !slices.Equal(got, want) {
+			t.Errorf("\ngot %v\nwant %v", got, want)
+		}
+	})
+
+	t.Run("when root merge - should extract custom and tag-based values of top-level values", func(t *testing.T) {
+		enc := oteltag.NewEncoder(oteltag.WithRootMerge())
+		m := testCart{ID: "c1", Items: []int64{1, 2, 3}}
+
+		want := []attribute.KeyValue{
+			attribute.Int("cart.size", 3),
+			attribute.String("cart.id", "c1"),
+			attribute.Int64Slice("cart.items", []int64{1, 2, 3}),
+		}
+
+		got := enc.SpanAttributes(m)
+		if !slices.Equal(got, want) {
+			t.Errorf("\ngot %v\nwant %v", got, want)
+		}
+
+		wantMembers := []string{"cart.size=3", "cart.id=c1", "cart.items=1,2,3"}
+
+		members := enc.BaggageMembers(m)
+		gotMembers := make([]string, len(members))
+		for i, m := range members {
+			gotMembers[i] = m.Key() + "=" + m.Value()
+		}
+
+		if !slices.Equal(gotMembers, wantMembers) {
+			t.Errorf("\ngot %v\nwant %v", gotMembers, wantMembers)
+		}
+	})
+}
+
+func TestValidateTags(t *testing.T) {
+	t.Run("when valid tags - should return nil", func(t *testing.T) {
+		if err := oteltag.ValidateTags(newPrefixTestModel()); err != nil {
+			t.Errorf("\ngot error %v\nwant nil", err)
+		}
+	})
+
+	t.Run("when invalid tag behind a nil pointer - should return an invalid tag error", func(t *testing.T) {
+		type details struct {
+			Bio string `otel:"bio,omitmpty"`
+		}
+
+		type node struct {
+			Name    string `otel:"name"`
+			Next    *node  `otel:"next,prefix"`
+			Details *details
+		}
+
+		err := oteltag.ValidateTags((*node)(nil))
+
+		var tagErr *oteltag.InvalidTagError
+		if !errors.As(err, &tagErr) {
+			t.Fatalf("\ngot error %v\nwant %T", err, tagErr)
+		}
+
+		if tagErr.Field != "Details.Bio" || tagErr.Tag != "bio,omitmpty" {
+			t.Errorf("\ngot field %q and tag %q\nwant %q and %q", tagErr.Field, tagErr.Tag, "Details.Bio", "bio,omitmpty")
+		}
+	})
+
+	t.Run("when not a struct - should return an unsupported type error", func(t *testing.T) {
+		var typeErr *oteltag.UnsupportedTypeError
+		if err := oteltag.ValidateTags(42); !errors.As(err, &typeErr) {
+			t.Errorf("\ngot error %v\nwant %T", err, typeErr)
+		}
+	})
+}
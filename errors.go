@@ -0,0 +1,179 @@
+package oteltag
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/remychantenay/otel-tag/internal"
+)
+
+var (
+	// ErrUnknownTagOption is the error of an [InvalidTagError] for an option that does not exist (e.g. a typo).
+	ErrUnknownTagOption = internal.ErrUnknownOption
+
+	// ErrMalformedTagOption is the error of an [InvalidTagError] for an option that is empty,
+	// misses its value, has an unexpected or invalid value (e.g. omitempty=true or unit=hours).
+	ErrMalformedTagOption = internal.ErrMalformedOption
+
+	// ErrMissingHashKey is the error of an [InvalidTagError] for a field tagged with hash
+	// while no key was provided with [WithHashKey].
+	ErrMissingHashKey = errors.New("missing hash key")
+)
+
+// An UnsupportedTypeError is returned when attempting to extract a value of an unsupported type.
+type UnsupportedTypeError struct {
+	Field string // Path of the field (e.g. "User.Address.City"), empty if the top-level value is unsupported.
+	Type  reflect.Type
+}
+
+func (e *UnsupportedTypeError) Error() string {
+	typeName := "nil"
+	if e.Type != nil {
+		typeName = e.Type.String()
+	}
+
+	if e.Field == "" {
+		return "oteltag: unsupported type: " + typeName
+	}
+
+	return "oteltag: unsupported type " + typeName + " for field " + e.Field
+}
+
+// An InvalidKeyError is returned when a field's tag does not hold a valid attribute or member key.
+type InvalidKeyError struct {
+	Field string // Path of the field (e.g. "User.Address.City").
+	Key   string
+}
+
+func (e *InvalidKeyError) Error() string {
+	return "oteltag: invalid key " + strconv.Quote(e.Key) + " for field " + e.Field
+}
+
+// An InvalidTagError is returned when a field's tag holds an unknown or malformed option,
+// or an option the encoder is not configured for.
+type InvalidTagError struct {
+	Field  string // Path of the field (e.g. "User.Address.City").
+	Tag    string
+	Option string
+	Err    error // ErrUnknownTagOption, ErrMalformedTagOption or ErrMissingHashKey.
+}
+
+func (e *InvalidTagError) Error() string {
+	return "oteltag: invalid tag option " + strconv.Quote(e.Option) + " for field " + e.Field + ": " + e.Err.Error()
+}
+
+func (e *InvalidTagError) Unwrap() error {
+	return e.Err
+}
+
+// An InvalidBaggageValueError is returned when a field's value cannot be used as a baggage member value.
+type InvalidBaggageValueError struct {
+	Field string // Path of the field (e.g. "User.Address.City").
+	Key   string
+	Value string
+	Err   error
+}
+
+func (e *InvalidBaggageValueError) Error() string {
+	return "oteltag: invalid baggage value " + strconv.Quote(e.Value) + " for field " + e.Field + ": " + e.Err.Error()
+}
+
+func (e *InvalidBaggageValueError) Unwrap() error {
+	return e.Err
+}
+
+// A BaggageConflictError is returned by [ContextWithBaggage] with [MergeError] when a member
+// has the key of an existing member with a different value.
+type BaggageConflictError struct {
+	Key      string
+	Existing string // Value of the existing member.
+	Value    string // Value of the conflicting member.
+}
+
+func (e *BaggageConflictError) Error() string {
+	return "oteltag: baggage member " + strconv.Quote(e.Key) + " conflicts with existing value " +
+		strconv.Quote(e.Existing) + ": " + strconv.Quote(e.Value)
+}
+
+// An InvalidUnmarshalError describes an invalid argument passed to an Unmarshal function.
+// The argument must be a non-nil pointer to a struct.
+type InvalidUnmarshalError struct {
+	Type reflect.Type
+}
+
+func (e *InvalidUnmarshalError) Error() string {
+	if e.Type == nil {
+		return "oteltag: Unmarshal(nil)"
+	}
+
+	if e.Type.Kind() != reflect.Pointer {
+		return "oteltag: Unmarshal(non-pointer " + e.Type.String() + ")"
+	}
+
+	if e.Type.Elem().Kind() != reflect.Struct {
+		return "oteltag: Unmarshal(non-struct " + e.Type.String() + ")"
+	}
+
+	return "oteltag: Unmarshal(nil " + e.Type.String() + ")"
+}
+
+// An UnmarshalTypeError describes a value that was not appropriate for the field it was decoded into.
+type UnmarshalTypeError struct {
+	Field string // Path of the field (e.g. "User.Address.City").
+	Key   string
+	Value string // Description of the value (e.g. the baggage member value).
+	Type  reflect.Type
+	Err   error
+}
+
+func (e *UnmarshalTypeError) Error() string {
+	msg := "oteltag: cannot unmarshal " + e.Value + " into field " + e.Field + " of type " + e.Type.String()
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+
+	return msg
+}
+
+func (e *UnmarshalTypeError) Unwrap() error {
+	return e.Err
+}
+
+// A KeyMismatchError reports the keys that could not be matched when unmarshalling span attributes
+// with [WithStrictKeys].
+type KeyMismatchError struct {
+	Unknown []string // Attribute keys without a matching field.
+	Missing []string // Keys of fields not tagged with omitempty without a matching attribute.
+}
+
+func (e *KeyMismatchError) Error() string {
+	msg := "oteltag: key mismatch"
+	if len(e.Unknown) > 0 {
+		msg += ", unknown keys: " + strings.Join(e.Unknown, ", ")
+	}
+	if len(e.Missing) > 0 {
+		msg += ", missing keys: " + strings.Join(e.Missing, ", ")
+	}
+
+	return msg
+}
+
+// withParentField prefixes the field path held by err with the provided parent field name.
+func withParentField(err error, parent string) error {
+	switch e := err.(type) {
+	case *UnsupportedTypeError:
+		e.Field = parent + "." + e.Field
+	case *InvalidKeyError:
+		e.Field = parent + "." + e.Field
+	case *InvalidTagError:
+		e.Field = parent + "." + e.Field
+	case *InvalidBaggageValueError:
+		e.Field = parent + "." + e.Field
+	case *UnmarshalTypeError:
+		e.Field = parent + "." + e.Field
+	}
+
+	return err
+}
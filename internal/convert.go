@@ -0,0 +1,41 @@
+package internal
+
+import (
+	"reflect"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Converters looks up the custom converters registered for a type.
+type Converters interface {
+	// SpanConverter returns the span attribute value converter registered for t, nil if none.
+	SpanConverter(t reflect.Type) func(v reflect.Value) attribute.Value
+	// BaggageConverter returns the baggage value converter registered for t, nil if none.
+	BaggageConverter(t reflect.Type) func(v reflect.Value) string
+}
+
+// hasConverter reports whether a span or baggage converter is registered for t.
+func hasConverter(conv Converters, t reflect.Type) bool {
+	return conv != nil && (conv.SpanConverter(t) != nil || conv.BaggageConverter(t) != nil)
+}
+
+// convertedSpanAttribute returns the [SpanAttributeFunc] of a type with a registered span converter.
+// A nil pointer produces an invalid attribute, reported as a zero-value.
+func convertedSpanAttribute(convert func(v reflect.Value) attribute.Value) SpanAttributeFunc {
+	return func(attrKey attribute.Key, fieldValue reflect.Value) (attribute.KeyValue, bool) {
+		if !fieldValue.CanInterface() || fieldValue.Kind() == reflect.Pointer && fieldValue.IsNil() {
+			return attribute.KeyValue{}, true
+		}
+		return attribute.KeyValue{Key: attrKey, Value: convert(fieldValue)}, fieldValue.IsZero()
+	}
+}
+
+// convertedBaggageValueFormatter returns the baggage value formatter of a type with a registered baggage converter.
+func convertedBaggageValueFormatter(convert func(v reflect.Value) string) func(fieldValue reflect.Value) (string, bool) {
+	return func(fieldValue reflect.Value) (string, bool) {
+		if !fieldValue.CanInterface() || fieldValue.Kind() == reflect.Pointer && fieldValue.IsNil() {
+			return "", true
+		}
+		return convert(fieldValue), fieldValue.IsZero()
+	}
+}
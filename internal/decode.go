@@ -0,0 +1,294 @@
+package internal
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// BaggageValueParseFunc parses a baggage member value and stores the result in the provided field value.
+type BaggageValueParseFunc func(memberValue string, fieldValue reflect.Value) error
+
+// BaggageValueParser returns the [BaggageValueParseFunc] able to parse values of the provided type.
+// It is the inverse of the formatting used by [BaggageMember]. Returns nil if the type is not supported.
+func BaggageValueParser(t reflect.Type, tag Tag, conv Converters) BaggageValueParseFunc {
+	if hasConverter(conv, t) {
+		// Values produced by custom converters cannot be parsed back.
+		return nil
+	}
+
+	switch t {
+	case timeType:
+		return timeBaggageValueParser(tag)
+	case durationType:
+		return durationBaggageValueParser(tag)
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		parseElem := BaggageValueParser(t.Elem(), tag, conv)
+		if parseElem == nil {
+			return nil
+		}
+
+		return func(memberValue string, fieldValue reflect.Value) error {
+			elemValue := reflect.New(t.Elem())
+			if err := parseElem(memberValue, elemValue.Elem()); err != nil {
+				return err
+			}
+			fieldValue.Set(elemValue)
+			return nil
+		}
+	}
+
+	if isText(t, tag) {
+		// Values formatted by fmt.Stringer cannot be parsed back.
+		return textUnmarshaler(t)
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return func(memberValue string, fieldValue reflect.Value) error {
+			fieldValue.SetString(memberValue)
+			return nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(memberValue string, fieldValue reflect.Value) error {
+			v, err := strconv.ParseInt(memberValue, 10, t.Bits())
+			if err != nil {
+				return err
+			}
+			fieldValue.SetInt(v)
+			return nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return func(memberValue string, fieldValue reflect.Value) error {
+			v, err := strconv.ParseUint(memberValue, 10, t.Bits())
+			if err != nil {
+				return err
+			}
+			fieldValue.SetUint(v)
+			return nil
+		}
+	case reflect.Float32, reflect.Float64:
+		return func(memberValue string, fieldValue reflect.Value) error {
+			v, err := strconv.ParseFloat(memberValue, t.Bits())
+			if err != nil {
+				return err
+			}
+			fieldValue.SetFloat(v)
+			return nil
+		}
+	case reflect.Bool:
+		return func(memberValue string, fieldValue reflect.Value) error {
+			v, err := strconv.ParseBool(memberValue)
+			if err != nil {
+				return err
+			}
+			fieldValue.SetBool(v)
+			return nil
+		}
+	case reflect.Slice:
+		if k := t.Elem().Kind(); k == reflect.Slice || k == reflect.Pointer {
+			return nil
+		}
+
+		parseElem := BaggageValueParser(t.Elem(), Tag{Raw: tag.Raw}, nil)
+		if parseElem == nil {
+			return nil
+		}
+
+		sep := tag.SliceSeparator()
+		return func(memberValue string, fieldValue reflect.Value) error {
+			if memberValue == "" {
+				fieldValue.SetZero()
+				return nil
+			}
+
+			elems := strings.Split(memberValue, sep)
+			if memberValue == emptyElem {
+				elems = []string{""}
+			}
+			s := reflect.MakeSlice(t, len(elems), len(elems))
+			for i, elem := range elems {
+				if err := parseElem(unescapeElem(elem), s.Index(i)); err != nil {
+					return err
+				}
+			}
+			fieldValue.Set(s)
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// ErrAttributeTypeMismatch is returned by an [AttributeValueSetFunc] when the attribute type
+// does not match the field type.
+var ErrAttributeTypeMismatch = errors.New("attribute type mismatch")
+
+// AttributeValueSetFunc stores a span attribute value in the provided field value.
+type AttributeValueSetFunc func(attrValue attribute.Value, fieldValue reflect.Value) error
+
+// AttributeValueSetter returns the [AttributeValueSetFunc] able to set fields of the provided type.
+// It is the inverse of [SpanAttribute]. Returns nil if the type is not supported.
+func AttributeValueSetter(t reflect.Type, tag Tag, conv Converters) AttributeValueSetFunc {
+	if hasConverter(conv, t) {
+		// Values produced by custom converters cannot be parsed back.
+		return nil
+	}
+
+	switch t {
+	case timeType:
+		return timeAttributeValueSetter(tag)
+	case durationType:
+		return durationAttributeValueSetter(tag)
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		setElem := AttributeValueSetter(t.Elem(), tag, conv)
+		if setElem == nil {
+			return nil
+		}
+
+		return func(attrValue attribute.Value, fieldValue reflect.Value) error {
+			elemValue := reflect.New(t.Elem())
+			if err := setElem(attrValue, elemValue.Elem()); err != nil {
+				return err
+			}
+			fieldValue.Set(elemValue)
+			return nil
+		}
+	}
+
+	if isText(t, tag) {
+		// Values formatted by fmt.Stringer cannot be parsed back.
+		return textAttributeValueSetter(t)
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return func(attrValue attribute.Value, fieldValue reflect.Value) error {
+			if attrValue.Type() != attribute.STRING {
+				return ErrAttributeTypeMismatch
+			}
+			fieldValue.SetString(attrValue.AsString())
+			return nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(attrValue attribute.Value, fieldValue reflect.Value) error {
+			if attrValue.Type() != attribute.INT64 {
+				return ErrAttributeTypeMismatch
+			}
+			v := attrValue.AsInt64()
+			if fieldValue.OverflowInt(v) {
+				return strconv.ErrRange
+			}
+			fieldValue.SetInt(v)
+			return nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return func(attrValue attribute.Value, fieldValue reflect.Value) error {
+			var v uint64
+			switch attrValue.Type() {
+			case attribute.INT64:
+				i := attrValue.AsInt64()
+				if i < 0 {
+					return strconv.ErrRange
+				}
+				v = uint64(i)
+			case attribute.STRING: // Values greater than math.MaxInt64.
+				var err error
+				if v, err = strconv.ParseUint(attrValue.AsString(), 10, 64); err != nil {
+					return err
+				}
+			default:
+				return ErrAttributeTypeMismatch
+			}
+			if fieldValue.OverflowUint(v) {
+				return strconv.ErrRange
+			}
+			fieldValue.SetUint(v)
+			return nil
+		}
+	case reflect.Float32, reflect.Float64:
+		return func(attrValue attribute.Value, fieldValue reflect.Value) error {
+			if attrValue.Type() != attribute.FLOAT64 {
+				return ErrAttributeTypeMismatch
+			}
+			v := attrValue.AsFloat64()
+			if fieldValue.OverflowFloat(v) {
+				return strconv.ErrRange
+			}
+			fieldValue.SetFloat(v)
+			return nil
+		}
+	case reflect.Bool:
+		return func(attrValue attribute.Value, fieldValue reflect.Value) error {
+			if attrValue.Type() != attribute.BOOL {
+				return ErrAttributeTypeMismatch
+			}
+			fieldValue.SetBool(attrValue.AsBool())
+			return nil
+		}
+	case reflect.Slice:
+		if k := t.Elem().Kind(); k == reflect.Slice || k == reflect.Pointer {
+			return nil
+		}
+
+		setElem := AttributeValueSetter(t.Elem(), Tag{Raw: tag.Raw}, nil)
+		if setElem == nil {
+			return nil
+		}
+
+		return func(attrValue attribute.Value, fieldValue reflect.Value) error {
+			elems, ok := sliceElems(attrValue)
+			if !ok {
+				return ErrAttributeTypeMismatch
+			}
+
+			s := reflect.MakeSlice(t, len(elems), len(elems))
+			for i, elem := range elems {
+				if err := setElem(elem, s.Index(i)); err != nil {
+					return err
+				}
+			}
+			fieldValue.Set(s)
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// sliceElems returns the elements of a slice attribute value as individual attribute values.
+// Returns false if the value is not a slice.
+func sliceElems(attrValue attribute.Value) ([]attribute.Value, bool) {
+	var elems []attribute.Value
+	switch attrValue.Type() {
+	case attribute.STRINGSLICE:
+		for _, v := range attrValue.AsStringSlice() {
+			elems = append(elems, attribute.StringValue(v))
+		}
+	case attribute.INT64SLICE:
+		for _, v := range attrValue.AsInt64Slice() {
+			elems = append(elems, attribute.Int64Value(v))
+		}
+	case attribute.FLOAT64SLICE:
+		for _, v := range attrValue.AsFloat64Slice() {
+			elems = append(elems, attribute.Float64Value(v))
+		}
+	case attribute.BOOLSLICE:
+		for _, v := range attrValue.AsBoolSlice() {
+			elems = append(elems, attribute.BoolValue(v))
+		}
+	default:
+		return nil, false
+	}
+
+	return elems, true
+}
@@ -0,0 +1,74 @@
+package internal
+
+import "strings"
+
+const upperHex = "0123456789ABCDEF"
+
+// emptyElem is the baggage value of a slice holding a single empty element, telling it apart from an empty slice.
+// Escaped elements never consist of a lone '%', which is escaped as "%25".
+const emptyElem = "%"
+
+// escapeElem percent-encodes the '%' characters and the bytes of sep in the slice element
+// held by buf[start:], so that joined elements can be split back unambiguously.
+// The W3C percent-encoding of the whole value is left to [baggage.Member.String].
+func escapeElem(buf []byte, start int, sep string) []byte {
+	elem := buf[start:]
+	escaped := 0
+	for _, c := range elem {
+		if c == '%' || strings.IndexByte(sep, c) >= 0 {
+			escaped++
+		}
+	}
+	if escaped == 0 {
+		return buf
+	}
+
+	elemCopy := string(elem)
+	buf = buf[:start]
+	for i := 0; i < len(elemCopy); i++ {
+		c := elemCopy[i]
+		if c == '%' || strings.IndexByte(sep, c) >= 0 {
+			buf = append(buf, '%', upperHex[c>>4], upperHex[c&0xF])
+			continue
+		}
+		buf = append(buf, c)
+	}
+
+	return buf
+}
+
+// unescapeElem decodes the percent-encoded bytes of a slice element escaped by [escapeElem].
+// Invalid sequences are kept as is, as produced by encoders that do not escape elements.
+func unescapeElem(elem string) string {
+	if strings.IndexByte(elem, '%') < 0 {
+		return elem
+	}
+
+	var b strings.Builder
+	b.Grow(len(elem))
+	for i := 0; i < len(elem); i++ {
+		if elem[i] == '%' && i+2 < len(elem) && isHex(elem[i+1]) && isHex(elem[i+2]) {
+			b.WriteByte(unhex(elem[i+1])<<4 | unhex(elem[i+2]))
+			i += 2
+			continue
+		}
+		b.WriteByte(elem[i])
+	}
+
+	return b.String()
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+func unhex(c byte) byte {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0'
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10
+	default:
+		return c - 'A' + 10
+	}
+}
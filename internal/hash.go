@@ -0,0 +1,28 @@
+package internal
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+)
+
+// Hasher computes the keyed hashes of the values of the fields tagged with hash.
+type Hasher struct {
+	Secret []byte
+	Size   int  // Number of bytes of the HMAC-SHA256 kept, from 1 to [sha256.Size].
+	Base64 bool // URL-safe base64 without padding rather than hex.
+}
+
+// Hash returns the truncated and encoded HMAC-SHA256 of v.
+func (h *Hasher) Hash(v string) string {
+	mac := hmac.New(sha256.New, h.Secret)
+	_, _ = io.WriteString(mac, v)
+	sum := mac.Sum(nil)[:h.Size]
+
+	if h.Base64 {
+		return base64.RawURLEncoding.EncodeToString(sum)
+	}
+	return hex.EncodeToString(sum)
+}
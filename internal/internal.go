@@ -1,158 +1,489 @@
 package internal
 
 import (
+	"math"
 	"reflect"
 	"strconv"
-	"strings"
 
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/baggage"
 )
 
-// tagName used by this library.
-const tagName = "otel"
+// SpanAttributeFunc creates and returns an OpenTelemetry span attribute for the provided field value.
+// Also returns a boolean that indicates whether or not the field's value is a zero-value.
+type SpanAttributeFunc func(attrKey attribute.Key, fieldValue reflect.Value) (attribute.KeyValue, bool)
+
+// BaggageMemberFunc creates and returns an OpenTelemetry baggage member for the provided field value.
+// Also returns a boolean that indicates whether or not the field's value is a zero-value.
+// The returned error is a [*BaggageValueError] if the value cannot be used as a member value.
+type BaggageMemberFunc func(memberKey string, fieldValue reflect.Value) (baggage.Member, bool, error)
 
-// ExtractTag extract the tag's value of a given Struct field index.
-func ExtractTag(value reflect.Value, index int) string {
-	return value.Type().Field(index).Tag.Get(tagName)
+// BaggageValueError is returned by a [BaggageMemberFunc] when the formatted value is rejected by [baggage.NewMemberRaw].
+type BaggageValueError struct {
+	Value string
+	Err   error
 }
 
-// SpanAttribute creates and returns an OpenTelemetry span attribute for the provided field.
-// Also returns a boolean that indicates whether or not the field's value is a zero-value.
-func SpanAttribute(field reflect.StructField, fieldValue reflect.Value, attrKey string) (attribute.KeyValue, bool) {
-	switch field.Type.Kind() {
+func (e *BaggageValueError) Error() string {
+	return e.Err.Error()
+}
+
+// ValidBaggageKey reports whether the provided key can be used as a baggage member key.
+func ValidBaggageKey(memberKey string) bool {
+	_, err := baggage.NewMemberRaw(memberKey, "")
+	return err == nil
+}
+
+// SpanAttribute returns the [SpanAttributeFunc] able to convert values of the provided type.
+// Returns nil if the type is not supported.
+//
+// The converter registered for the type in conv, if any, takes precedence over the built-in conversions.
+//
+// Pointers are dereferenced. A nil pointer produces the attribute of the pointed type's zero-value and
+// is reported as a zero-value, while a non-nil pointer is never reported as a zero-value.
+//
+// Unsigned integers greater than [math.MaxInt64] cannot be represented as int64 attributes,
+// they produce string attributes instead (a string slice attribute if any element of a slice overflows).
+//
+// Values of fields tagged with redact or mask are redacted, see [Tag.Redactor].
+func SpanAttribute(t reflect.Type, tag Tag, conv Converters) SpanAttributeFunc {
+	fn := spanAttribute(t, tag, conv)
+	if fn == nil || !tag.Redacted() {
+		return fn
+	}
+
+	return redactedSpanAttribute(fn, tag)
+}
+
+// spanAttribute returns the [SpanAttributeFunc] of [SpanAttribute], without redaction.
+func spanAttribute(t reflect.Type, tag Tag, conv Converters) SpanAttributeFunc {
+	if conv != nil {
+		if convert := conv.SpanConverter(t); convert != nil {
+			return convertedSpanAttribute(convert)
+		}
+	}
+
+	switch t {
+	case timeType:
+		return timeSpanAttribute(tag)
+	case durationType:
+		return durationSpanAttribute(tag)
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		elemAttribute := spanAttribute(t.Elem(), tag, conv)
+		if elemAttribute == nil {
+			return nil
+		}
+
+		zeroElem := reflect.Zero(t.Elem())
+		return func(attrKey attribute.Key, fieldValue reflect.Value) (attribute.KeyValue, bool) {
+			if fieldValue.IsNil() {
+				attr, _ := elemAttribute(attrKey, zeroElem)
+				return attr, true
+			}
+
+			attr, _ := elemAttribute(attrKey, fieldValue.Elem())
+			return attr, false
+		}
+	}
+
+	if isText(t, tag) {
+		return textSpanAttribute(t)
+	}
+
+	switch t.Kind() {
 	case reflect.String:
-		v := fieldValue.String()
-		return attribute.String(attrKey, v), v == ""
-	case reflect.Int:
-		v := fieldValue.Int()
-		return attribute.Int(attrKey, int(v)), v == 0
-	case reflect.Int64:
-		v := fieldValue.Int()
-		return attribute.Int64(attrKey, v), v == 0
+		return func(attrKey attribute.Key, fieldValue reflect.Value) (attribute.KeyValue, bool) {
+			v := fieldValue.String()
+			return attrKey.String(v), v == ""
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(attrKey attribute.Key, fieldValue reflect.Value) (attribute.KeyValue, bool) {
+			v := fieldValue.Int()
+			return attrKey.Int64(v), v == 0
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return func(attrKey attribute.Key, fieldValue reflect.Value) (attribute.KeyValue, bool) {
+			v := fieldValue.Uint()
+			if v > math.MaxInt64 {
+				return attrKey.String(strconv.FormatUint(v, 10)), false
+			}
+			return attrKey.Int64(int64(v)), v == 0
+		}
+	case reflect.Float32:
+		return func(attrKey attribute.Key, fieldValue reflect.Value) (attribute.KeyValue, bool) {
+			v := fieldValue.Float()
+			return attrKey.Float64(widenFloat32(v)), v == 0.0
+		}
 	case reflect.Float64:
-		v := fieldValue.Float()
-		return attribute.Float64(attrKey, v), v == 0.0
+		return func(attrKey attribute.Key, fieldValue reflect.Value) (attribute.KeyValue, bool) {
+			v := fieldValue.Float()
+			return attrKey.Float64(v), v == 0.0
+		}
 	case reflect.Bool:
-		v := fieldValue.Bool()
-		return attribute.Bool(attrKey, v), !v
+		return func(attrKey attribute.Key, fieldValue reflect.Value) (attribute.KeyValue, bool) {
+			v := fieldValue.Bool()
+			return attrKey.Bool(v), !v
+		}
 	case reflect.Slice:
-		switch field.Type.Elem().Kind() {
-		case reflect.String:
+		return sliceSpanAttribute(t, tag)
+	}
+
+	return nil
+}
+
+// sliceSpanAttribute returns the [SpanAttributeFunc] able to convert slices of the provided type.
+// Returns nil if the element type is not supported.
+func sliceSpanAttribute(t reflect.Type, tag Tag) SpanAttributeFunc {
+	convertElems := elemsSpanAttribute(t, tag)
+	fast := fastSliceSpanAttribute(t)
+	if convertElems == nil || fast == nil {
+		return convertElems
+	}
+
+	return func(attrKey attribute.Key, fieldValue reflect.Value) (attribute.KeyValue, bool) {
+		// Values of unexported fields cannot be used through Interface.
+		if !fieldValue.CanInterface() {
+			return convertElems(attrKey, fieldValue)
+		}
+		return fast(attrKey, fieldValue)
+	}
+}
+
+// fastSliceSpanAttribute returns the [SpanAttributeFunc] of unnamed slice types,
+// avoiding the conversion of every element. Returns nil for other types.
+func fastSliceSpanAttribute(t reflect.Type) SpanAttributeFunc {
+	switch t {
+	case reflect.TypeFor[[]string]():
+		return func(attrKey attribute.Key, fieldValue reflect.Value) (attribute.KeyValue, bool) {
 			s := fieldValue.Interface().([]string)
-			return attribute.StringSlice(attrKey, s), len(s) == 0
-		case reflect.Int:
+			return attrKey.StringSlice(s), len(s) == 0
+		}
+	case reflect.TypeFor[[]int]():
+		return func(attrKey attribute.Key, fieldValue reflect.Value) (attribute.KeyValue, bool) {
 			s := fieldValue.Interface().([]int)
-			return attribute.IntSlice(attrKey, s), len(s) == 0
-		case reflect.Int64:
+			return attrKey.IntSlice(s), len(s) == 0
+		}
+	case reflect.TypeFor[[]int64]():
+		return func(attrKey attribute.Key, fieldValue reflect.Value) (attribute.KeyValue, bool) {
 			s := fieldValue.Interface().([]int64)
-			return attribute.Int64Slice(attrKey, s), len(s) == 0
-		case reflect.Float64:
+			return attrKey.Int64Slice(s), len(s) == 0
+		}
+	case reflect.TypeFor[[]float64]():
+		return func(attrKey attribute.Key, fieldValue reflect.Value) (attribute.KeyValue, bool) {
 			s := fieldValue.Interface().([]float64)
-			return attribute.Float64Slice(attrKey, s), len(s) == 0
-		case reflect.Bool:
+			return attrKey.Float64Slice(s), len(s) == 0
+		}
+	case reflect.TypeFor[[]bool]():
+		return func(attrKey attribute.Key, fieldValue reflect.Value) (attribute.KeyValue, bool) {
 			s := fieldValue.Interface().([]bool)
-			return attribute.BoolSlice(attrKey, s), len(s) == 0
+			return attrKey.BoolSlice(s), len(s) == 0
 		}
 	}
 
-	return attribute.KeyValue{}, true
+	return nil
 }
 
-// BaggageMember creates and returns an OpenTelemetry baggageMember for the provided field.
-// Also returns a boolean that indicates whether or not the field's value is a zero-value.
-func BaggageMember(field reflect.StructField, fieldValue reflect.Value, memberKey string) (baggage.Member, bool) {
-	switch field.Type.Kind() {
-	case reflect.String:
-		v := fieldValue.String()
-		m, err := baggage.NewMemberRaw(memberKey, v)
-		if err != nil {
-			return baggage.Member{}, true
-		}
-		return m, v == ""
-	case reflect.Int:
-		v := fieldValue.Int()
-		m, err := baggage.NewMemberRaw(memberKey, strconv.Itoa(int(v)))
-		if err != nil {
-			return baggage.Member{}, true
-		}
-		return m, v == 0
-	case reflect.Int64:
-		v := fieldValue.Int()
-		m, err := baggage.NewMemberRaw(memberKey, strconv.FormatInt(v, 10))
-		if err != nil {
-			return baggage.Member{}, true
+// elemsSpanAttribute returns the [SpanAttributeFunc] converting slices of the provided type element by element,
+// including slices of named types (e.g. []Role where Role is a string).
+// Returns nil if the element type is not supported.
+func elemsSpanAttribute(t reflect.Type, tag Tag) SpanAttributeFunc {
+	if isText(t.Elem(), tag) {
+		formatElem := textFormatter(t.Elem())
+		return func(attrKey attribute.Key, fieldValue reflect.Value) (attribute.KeyValue, bool) {
+			n := fieldValue.Len()
+			s := make([]string, n)
+			for i := 0; i < n; i++ {
+				s[i], _ = formatElem(fieldValue.Index(i))
+			}
+			return attrKey.StringSlice(s), n == 0
 		}
-		return m, v == 0
-	case reflect.Float64:
-		v := fieldValue.Float()
-		m, err := baggage.NewMemberRaw(memberKey, strconv.FormatFloat(v, 'f', -1, 64))
-		if err != nil {
-			return baggage.Member{}, true
+	}
+
+	switch t.Elem().Kind() {
+	case reflect.String:
+		return func(attrKey attribute.Key, fieldValue reflect.Value) (attribute.KeyValue, bool) {
+			n := fieldValue.Len()
+			s := make([]string, n)
+			for i := 0; i < n; i++ {
+				s[i] = fieldValue.Index(i).String()
+			}
+			return attrKey.StringSlice(s), n == 0
 		}
-		return m, v == 0.0
 	case reflect.Bool:
-		v := fieldValue.Bool()
-		m, err := baggage.NewMemberRaw(memberKey, strconv.FormatBool(v))
-		if err != nil {
-			return baggage.Member{}, true
+		return func(attrKey attribute.Key, fieldValue reflect.Value) (attribute.KeyValue, bool) {
+			n := fieldValue.Len()
+			s := make([]bool, n)
+			for i := 0; i < n; i++ {
+				s[i] = fieldValue.Index(i).Bool()
+			}
+			return attrKey.BoolSlice(s), n == 0
 		}
-		return m, !v
-	case reflect.Slice:
-		switch field.Type.Elem().Kind() {
-		case reflect.String:
-			s := fieldValue.Interface().([]string)
-			m, err := baggage.NewMemberRaw(memberKey, strings.Join(s, ","))
-			if err != nil {
-				return baggage.Member{}, true
+	case reflect.Float64:
+		return func(attrKey attribute.Key, fieldValue reflect.Value) (attribute.KeyValue, bool) {
+			n := fieldValue.Len()
+			s := make([]float64, n)
+			for i := 0; i < n; i++ {
+				s[i] = fieldValue.Index(i).Float()
 			}
-			return m, len(s) == 0
-		case reflect.Int:
-			s := fieldValue.Interface().([]int)
-			sStr := make([]string, len(s))
-			for i, v := range s {
-				sStr[i] = strconv.Itoa(int(v))
+			return attrKey.Float64Slice(s), n == 0
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(attrKey attribute.Key, fieldValue reflect.Value) (attribute.KeyValue, bool) {
+			n := fieldValue.Len()
+			s := make([]int64, n)
+			for i := 0; i < n; i++ {
+				s[i] = fieldValue.Index(i).Int()
 			}
-			m, err := baggage.NewMemberRaw(memberKey, strings.Join(sStr, ","))
-			if err != nil {
-				return baggage.Member{}, true
+			return attrKey.Int64Slice(s), n == 0
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return func(attrKey attribute.Key, fieldValue reflect.Value) (attribute.KeyValue, bool) {
+			n := fieldValue.Len()
+			s := make([]int64, n)
+			for i := 0; i < n; i++ {
+				v := fieldValue.Index(i).Uint()
+				if v > math.MaxInt64 {
+					return attrKey.StringSlice(uintStrings(fieldValue)), false
+				}
+				s[i] = int64(v)
 			}
-			return m, len(s) == 0
-		case reflect.Int64:
-			s := fieldValue.Interface().([]int64)
-			sStr := make([]string, len(s))
-			for i, v := range s {
-				sStr[i] = strconv.FormatInt(v, 10)
+			return attrKey.Int64Slice(s), n == 0
+		}
+	case reflect.Float32:
+		return func(attrKey attribute.Key, fieldValue reflect.Value) (attribute.KeyValue, bool) {
+			n := fieldValue.Len()
+			s := make([]float64, n)
+			for i := 0; i < n; i++ {
+				s[i] = widenFloat32(fieldValue.Index(i).Float())
 			}
-			m, err := baggage.NewMemberRaw(memberKey, strings.Join(sStr, ","))
+			return attrKey.Float64Slice(s), n == 0
+		}
+	}
+
+	return nil
+}
+
+// uintStrings returns the decimal representation of every element of a slice of unsigned integers.
+func uintStrings(fieldValue reflect.Value) []string {
+	s := make([]string, fieldValue.Len())
+	for i := range s {
+		s[i] = strconv.FormatUint(fieldValue.Index(i).Uint(), 10)
+	}
+	return s
+}
+
+// widenFloat32 converts a float32 value to the float64 value sharing its shortest decimal representation,
+// so that 0.1 remains 0.1 instead of 0.10000000149011612.
+func widenFloat32(v float64) float64 {
+	widened, err := strconv.ParseFloat(strconv.FormatFloat(v, 'g', -1, 32), 64)
+	if err != nil {
+		return v
+	}
+	return widened
+}
+
+// BaggageMember returns the [BaggageMemberFunc] able to convert values of the provided type.
+// Returns nil if the type is not supported.
+//
+// Values of fields tagged with redact, hash or mask are redacted, see [Tag.Redactor],
+// slices element by element unless tagged with redact.
+// Members get the properties of the prop options and of the values implementing propertier.
+func BaggageMember(t reflect.Type, tag Tag, conv Converters) BaggageMemberFunc {
+	format := baggageValueFormatter(t, tag, conv)
+	if format == nil {
+		return nil
+	}
+	if tag.Redacted() && (tag.Redact || !IsJoined(t, tag, conv)) {
+		format = redactedBaggageValueFormatter(format, tag)
+	}
+
+	properties := memberProperties(t, tag)
+	if properties == nil {
+		return func(memberKey string, fieldValue reflect.Value) (baggage.Member, bool, error) {
+			v, zero := format(fieldValue)
+			m, err := baggage.NewMemberRaw(memberKey, v)
 			if err != nil {
-				return baggage.Member{}, true
+				return baggage.Member{}, zero, &BaggageValueError{Value: v, Err: err}
 			}
-			return m, len(s) == 0
-		case reflect.Float64:
-			s := fieldValue.Interface().([]float64)
-			sStr := make([]string, len(s))
-			for i, v := range s {
-				sStr[i] = strconv.FormatFloat(v, 'f', -1, 64)
+			return m, zero, nil
+		}
+	}
+
+	return func(memberKey string, fieldValue reflect.Value) (baggage.Member, bool, error) {
+		v, zero := format(fieldValue)
+		m, err := baggage.NewMemberRaw(memberKey, v, properties(fieldValue)...)
+		if err != nil {
+			return baggage.Member{}, zero, &BaggageValueError{Value: v, Err: err}
+		}
+		return m, zero, nil
+	}
+}
+
+// IsJoined reports whether values of the provided type are slices whose elements are joined
+// with the tag slice separator in baggage values.
+func IsJoined(t reflect.Type, tag Tag, conv Converters) bool {
+	converted := func(t reflect.Type) bool {
+		return conv != nil && conv.BaggageConverter(t) != nil
+	}
+
+	if t.Kind() == reflect.Pointer && !converted(t) {
+		t = t.Elem()
+	}
+
+	return t.Kind() == reflect.Slice && !converted(t) && !isText(t, tag)
+}
+
+// baggageValueFormatter returns a function formatting values of the provided type as a baggage value.
+// Slices are joined with the tag slice separator, their elements escaped with [escapeElem]
+// (a single empty element being encoded as [emptyElem]), and pointers are dereferenced like in [SpanAttribute].
+// Returns nil if the type is not supported.
+func baggageValueFormatter(t reflect.Type, tag Tag, conv Converters) func(fieldValue reflect.Value) (string, bool) {
+	if conv != nil {
+		if convert := conv.BaggageConverter(t); convert != nil {
+			return convertedBaggageValueFormatter(convert)
+		}
+	}
+
+	switch t {
+	case timeType:
+		return timeBaggageValueFormatter(tag)
+	case durationType:
+		return durationBaggageValueFormatter(tag)
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		formatElem := baggageValueFormatter(t.Elem(), tag, conv)
+		if formatElem == nil {
+			return nil
+		}
+
+		zeroElem := reflect.Zero(t.Elem())
+		return func(fieldValue reflect.Value) (string, bool) {
+			if fieldValue.IsNil() {
+				v, _ := formatElem(zeroElem)
+				return v, true
 			}
-			m, err := baggage.NewMemberRaw(memberKey, strings.Join(sStr, ","))
-			if err != nil {
-				return baggage.Member{}, true
+
+			v, _ := formatElem(fieldValue.Elem())
+			return v, false
+		}
+	}
+
+	if isText(t, tag) {
+		return textBaggageValueFormatter(t)
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return func(fieldValue reflect.Value) (string, bool) {
+			v := fieldValue.String()
+			return v, v == ""
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(fieldValue reflect.Value) (string, bool) {
+			v := fieldValue.Int()
+			return strconv.FormatInt(v, 10), v == 0
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return func(fieldValue reflect.Value) (string, bool) {
+			v := fieldValue.Uint()
+			return strconv.FormatUint(v, 10), v == 0
+		}
+	case reflect.Float32, reflect.Float64:
+		bitSize := t.Bits()
+		return func(fieldValue reflect.Value) (string, bool) {
+			v := fieldValue.Float()
+			return strconv.FormatFloat(v, 'f', -1, bitSize), v == 0.0
+		}
+	case reflect.Bool:
+		return func(fieldValue reflect.Value) (string, bool) {
+			v := fieldValue.Bool()
+			return strconv.FormatBool(v), !v
+		}
+	case reflect.Slice:
+		appendElem := baggageElemAppender(t.Elem(), tag)
+		if appendElem == nil {
+			return nil
+		}
+
+		sep := tag.SliceSeparator()
+		redactElem := tag.elemRedactor()
+		return func(fieldValue reflect.Value) (string, bool) {
+			n := fieldValue.Len()
+			if n == 0 {
+				return "", true
 			}
-			return m, len(s) == 0
-		case reflect.Bool:
-			s := fieldValue.Interface().([]bool)
-			sStr := make([]string, len(s))
-			for i, v := range s {
-				sStr[i] = strconv.FormatBool(v)
+
+			buf := make([]byte, 0, 8*n)
+			for i := 0; i < n; i++ {
+				if i > 0 {
+					buf = append(buf, sep...)
+				}
+				start := len(buf)
+				buf = appendElem(buf, fieldValue.Index(i))
+				if redactElem != nil {
+					buf = append(buf[:start], redactElem(string(buf[start:]))...)
+				}
+				buf = escapeElem(buf, start, sep)
 			}
-			m, err := baggage.NewMemberRaw(memberKey, strings.Join(sStr, ","))
-			if err != nil {
-				return baggage.Member{}, true
+			if len(buf) == 0 {
+				return emptyElem, false
 			}
-			return m, len(s) == 0
+			return string(buf), false
+		}
+	}
+
+	return nil
+}
+
+// baggageElemAppender returns a function appending a slice element of the provided type to a buffer.
+// Returns nil if the type is not supported.
+func baggageElemAppender(t reflect.Type, tag Tag) func(buf []byte, elemValue reflect.Value) []byte {
+	if isText(t, tag) {
+		formatElem := textFormatter(t)
+		return func(buf []byte, elemValue reflect.Value) []byte {
+			v, _ := formatElem(elemValue)
+			return append(buf, v...)
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return func(buf []byte, elemValue reflect.Value) []byte {
+			return append(buf, elemValue.String()...)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(buf []byte, elemValue reflect.Value) []byte {
+			return strconv.AppendInt(buf, elemValue.Int(), 10)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return func(buf []byte, elemValue reflect.Value) []byte {
+			return strconv.AppendUint(buf, elemValue.Uint(), 10)
+		}
+	case reflect.Float32, reflect.Float64:
+		bitSize := t.Bits()
+		return func(buf []byte, elemValue reflect.Value) []byte {
+			return strconv.AppendFloat(buf, elemValue.Float(), 'f', -1, bitSize)
+		}
+	case reflect.Bool:
+		return func(buf []byte, elemValue reflect.Value) []byte {
+			return strconv.AppendBool(buf, elemValue.Bool())
 		}
 	}
 
-	return baggage.Member{}, true
+	return nil
+}
+
+// InterfaceOf returns the value held by v as a T.
+// Returns false if v cannot be used through Interface, i.e. if it was obtained through an unexported field.
+func InterfaceOf[T any](v reflect.Value) (T, bool) {
+	if !v.CanInterface() {
+		var zero T
+		return zero, false
+	}
+	return v.Interface().(T), true
 }
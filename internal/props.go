@@ -0,0 +1,82 @@
+package internal
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+
+	"go.opentelemetry.io/otel/baggage"
+)
+
+// propertier is implemented by types providing the properties of their baggage member,
+// it mirrors oteltag.BaggagePropertier.
+type propertier interface {
+	OtelBaggageProperties() []baggage.Property
+}
+
+var propertierType = reflect.TypeFor[propertier]()
+
+// errInvalidPropertyName is returned for property names that are not W3C Baggage tokens.
+var errInvalidPropertyName = errors.New("invalid property name")
+
+// newProperty returns the baggage member property described by a prop option value: name or name:value.
+// The name must be a W3C Baggage token so that the property can be propagated and parsed back.
+func newProperty(prop string) (baggage.Property, error) {
+	name, value, found := strings.Cut(prop, ":")
+	if !validPropertyName(name) {
+		return baggage.Property{}, errInvalidPropertyName
+	}
+	if !found {
+		return baggage.NewKeyProperty(name)
+	}
+	return baggage.NewKeyValuePropertyRaw(name, value)
+}
+
+// validPropertyName reports whether name is a non-empty token as defined by RFC 7230,
+// the grammar of W3C Baggage keys.
+func validPropertyName(name string) bool {
+	if name == "" {
+		return false
+	}
+
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || strings.IndexByte("!#$%&'*+-.^_`|~", c) >= 0) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// memberProperties returns a function returning the properties of the baggage member of a field value:
+// the ones of the tag followed by the ones provided by the value if its type implements propertier.
+// Returns nil if the members have no properties.
+func memberProperties(t reflect.Type, tag Tag) func(fieldValue reflect.Value) []baggage.Property {
+	var static []baggage.Property
+	for _, prop := range tag.Props {
+		if p, err := newProperty(prop); err == nil {
+			static = append(static, p)
+		}
+	}
+
+	if !Implements(t, propertierType) {
+		if len(static) == 0 {
+			return nil
+		}
+		return func(reflect.Value) []baggage.Property { return static }
+	}
+
+	return func(fieldValue reflect.Value) []baggage.Property {
+		if fieldValue.Kind() == reflect.Pointer && fieldValue.IsNil() {
+			return static
+		}
+
+		p, ok := MethodReceiver[propertier](fieldValue)
+		if !ok {
+			return static
+		}
+		return append(slices.Clip(static), p.OtelBaggageProperties()...)
+	}
+}
@@ -0,0 +1,182 @@
+package internal
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// RedactedValue replaces the values of the fields tagged with redact.
+const RedactedValue = "[REDACTED]"
+
+// maskRune replaces the hidden characters of the values of the fields tagged with mask.
+const maskRune = '*'
+
+// Redacted reports whether the values of the field must be redacted, hashed or masked.
+func (t Tag) Redacted() bool {
+	return t.Redact || t.Hash || t.Params[paramMask] != ""
+}
+
+// WithoutRedaction returns a copy of t that neither redacts nor masks values. Hashing is kept.
+func (t Tag) WithoutRedaction() Tag {
+	t.Redact = false
+	if t.Params[paramMask] != "" {
+		params := make(map[string]string, len(t.Params))
+		for k, v := range t.Params {
+			if k != paramMask {
+				params[k] = v
+			}
+		}
+		t.Params = params
+	}
+
+	return t
+}
+
+// Redactor returns the function redacting the string representation of a value:
+// redact replaces the whole value with [RedactedValue], hash with its keyed hash,
+// mask=lastN (or firstN) replaces all characters but the last (or first) N ones with asterisks,
+// all characters if the value is not longer than N.
+// Returns nil if the values must not be redacted.
+func (t Tag) Redactor() func(v string) string {
+	if t.Redact {
+		return func(string) string { return RedactedValue }
+	}
+	if t.Hash && t.Hasher != nil {
+		return t.Hasher.Hash
+	}
+
+	first, n, ok := parseMask(t.Params[paramMask])
+	if !ok {
+		return nil
+	}
+
+	return func(v string) string {
+		count := utf8.RuneCountInString(v)
+		if count <= n {
+			return strings.Repeat(string(maskRune), count)
+		}
+
+		var b strings.Builder
+		b.Grow(len(v))
+		i := 0
+		for _, r := range v {
+			if first && i >= n || !first && i < count-n {
+				r = maskRune
+			}
+			b.WriteRune(r)
+			i++
+		}
+		return b.String()
+	}
+}
+
+// elemRedactor returns the function redacting each element of slices: slices are hashed or masked
+// element by element, but redacted as a whole. Returns nil if the elements must not be redacted.
+func (t Tag) elemRedactor() func(v string) string {
+	if t.Redact {
+		return nil
+	}
+
+	return t.Redactor()
+}
+
+// parseMask parses a mask option value: firstN or lastN, N being a positive integer.
+func parseMask(value string) (first bool, n int, ok bool) {
+	digits, first := strings.CutPrefix(value, "first")
+	if !first {
+		if digits, ok = strings.CutPrefix(value, "last"); !ok {
+			return false, 0, false
+		}
+	}
+
+	n, err := strconv.Atoi(digits)
+	if err != nil || n < 1 || digits[0] == '+' {
+		return false, 0, false
+	}
+
+	return first, n, true
+}
+
+// redactedSpanAttribute wraps fn so that it returns redacted string attributes.
+// Hashed or masked slices are string slices of their elements, each of them being hashed or masked,
+// like the elements of baggage values.
+func redactedSpanAttribute(fn SpanAttributeFunc, tag Tag) SpanAttributeFunc {
+	redact := tag.Redactor()
+	return func(attrKey attribute.Key, fieldValue reflect.Value) (attribute.KeyValue, bool) {
+		attr, zero := fn(attrKey, fieldValue)
+		if !attr.Valid() {
+			return attr, zero
+		}
+
+		switch {
+		case tag.Redact:
+			return attrKey.String(RedactedValue), zero
+		case attr.Value.Type() == attribute.STRING:
+			return attrKey.String(redact(attr.Value.AsString())), zero
+		case isSliceAttribute(attr.Value):
+			s := attributeElems(attr.Value)
+			for i := range s {
+				s[i] = redact(s[i])
+			}
+			return attrKey.StringSlice(s), zero
+		case attr.Value.Type() == attribute.FLOAT64:
+			// Formatted like baggage values rather than with Emit, for both to be redacted alike.
+			return attrKey.String(redact(strconv.FormatFloat(attr.Value.AsFloat64(), 'f', -1, 64))), zero
+		default:
+			return attrKey.String(redact(attr.Value.Emit())), zero
+		}
+	}
+}
+
+// isSliceAttribute reports whether v holds a slice.
+func isSliceAttribute(v attribute.Value) bool {
+	switch v.Type() {
+	case attribute.STRINGSLICE, attribute.INT64SLICE, attribute.FLOAT64SLICE, attribute.BOOLSLICE:
+		return true
+	}
+	return false
+}
+
+// attributeElems returns the elements of a slice attribute value formatted like the elements of
+// baggage values.
+func attributeElems(v attribute.Value) []string {
+	switch v.Type() {
+	case attribute.INT64SLICE:
+		ints := v.AsInt64Slice()
+		s := make([]string, len(ints))
+		for i, n := range ints {
+			s[i] = strconv.FormatInt(n, 10)
+		}
+		return s
+	case attribute.FLOAT64SLICE:
+		floats := v.AsFloat64Slice()
+		s := make([]string, len(floats))
+		for i, f := range floats {
+			s[i] = strconv.FormatFloat(f, 'f', -1, 64)
+		}
+		return s
+	case attribute.BOOLSLICE:
+		bools := v.AsBoolSlice()
+		s := make([]string, len(bools))
+		for i, b := range bools {
+			s[i] = strconv.FormatBool(b)
+		}
+		return s
+	default:
+		return v.AsStringSlice()
+	}
+}
+
+// redactedBaggageValueFormatter wraps format so that it returns redacted values.
+// Joined slices are redacted element by element by [baggageValueFormatter] instead, see [Tag.elemRedactor].
+func redactedBaggageValueFormatter(format func(fieldValue reflect.Value) (string, bool), tag Tag) func(fieldValue reflect.Value) (string, bool) {
+	redact := tag.Redactor()
+	return func(fieldValue reflect.Value) (string, bool) {
+		v, zero := format(fieldValue)
+		return redact(v), zero
+	}
+}
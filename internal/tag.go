@@ -0,0 +1,219 @@
+package internal
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// DefaultTagName is the struct tag name used by default.
+const DefaultTagName = "otel"
+
+// DefaultSliceSeparator joins the elements of slices in baggage values by default.
+const DefaultSliceSeparator = ","
+
+// IgnoreTag is the tag value excluding a field.
+const IgnoreTag = "-"
+
+const (
+	flagOmitEmpty = "omitempty"
+	flagOmitZero  = "omitzero"
+	flagRaw       = "raw"
+	flagMerge     = "merge"
+	flagPrefix    = "prefix"
+	flagRedact    = "redact"
+	flagHash      = "hash"
+	flagBaggage   = "baggage"
+)
+
+const (
+	paramFormat   = "format"
+	paramUnit     = "unit"
+	paramMask     = "mask"
+	paramMax      = "max"
+	paramPriority = "priority"
+	paramProp     = "prop"
+)
+
+// Tag is a parsed struct tag, e.g. `otel:"app.created_at,omitempty,format=unix"`.
+type Tag struct {
+	Key       string
+	OmitEmpty bool
+
+	// OmitZero skips zero values, as reported by their IsZero method or reflect.Value.IsZero.
+	// Unlike OmitEmpty, it applies to nested structs too.
+	OmitZero bool
+
+	// Raw disables the conversion through encoding.TextMarshaler or fmt.Stringer, the value kind is used instead.
+	Raw bool
+
+	// Merge extracts both the custom and the tag-based values of a type providing its own values.
+	Merge bool
+
+	// Prefix prepends the key followed by a dot to the keys of the fields of a nested struct.
+	Prefix bool
+
+	// Redact replaces the value with [RedactedValue].
+	Redact bool
+
+	// Hash replaces the value with its keyed hash computed by Hasher.
+	Hash   bool
+	Hasher *Hasher
+
+	// Baggage scopes the field, or the fields of a nested struct, to the baggage set by oteltag.Start.
+	Baggage bool
+
+	// Props holds the baggage member properties of the prop options, as name or name:value.
+	Props []string
+
+	// Separator joins the elements of slices in baggage values, [DefaultSliceSeparator] if empty.
+	Separator string
+
+	// Params holds the key=value options (e.g. format=unix).
+	Params map[string]string
+}
+
+var (
+	// ErrUnknownOption is the error of a [TagOptionError] for an option that does not exist.
+	ErrUnknownOption = errors.New("unknown option")
+
+	// ErrMalformedOption is the error of a [TagOptionError] for an option that is empty,
+	// misses its value, has an unexpected or invalid value.
+	ErrMalformedOption = errors.New("malformed option")
+)
+
+// A TagOptionError describes an invalid tag option.
+type TagOptionError struct {
+	Option string
+	Err    error
+}
+
+func (e *TagOptionError) Error() string {
+	return e.Err.Error() + " " + e.Option
+}
+
+// flags holds the setters of the options without value.
+var flags = map[string]func(t *Tag){
+	flagOmitEmpty: func(t *Tag) { t.OmitEmpty = true },
+	flagOmitZero:  func(t *Tag) { t.OmitZero = true },
+	flagRaw:       func(t *Tag) { t.Raw = true },
+	flagMerge:     func(t *Tag) { t.Merge = true },
+	flagPrefix:    func(t *Tag) { t.Prefix = true },
+	flagRedact:    func(t *Tag) { t.Redact = true },
+	flagHash:      func(t *Tag) { t.Hash = true },
+	flagBaggage:   func(t *Tag) { t.Baggage = true },
+}
+
+// params holds the validators of the key=value options, nil if any non-empty value is valid.
+var params = map[string]func(value string) bool{
+	paramFormat: nil,
+	paramUnit: func(value string) bool {
+		switch value {
+		case "ns", "us", "ms", "s":
+			return true
+		}
+		return false
+	},
+	paramMax: func(value string) bool {
+		n, err := strconv.Atoi(value)
+		return err == nil && n > 0 && value[0] != '+'
+	},
+	paramPriority: func(value string) bool {
+		_, err := strconv.Atoi(value)
+		return err == nil
+	},
+	paramProp: func(value string) bool {
+		_, err := newProperty(value)
+		return err == nil
+	},
+	paramMask: func(value string) bool {
+		_, _, ok := parseMask(value)
+		return ok
+	},
+}
+
+// ExtractTag extract the tag's value of a given Struct field.
+// The [DefaultTagName] is used if name is empty.
+func ExtractTag(field reflect.StructField, name string) string {
+	if name == "" {
+		name = DefaultTagName
+	}
+	return field.Tag.Get(name)
+}
+
+// ParseTag parses the provided tag value: a key followed by any number of comma-separated options,
+// either flags (e.g. omitempty) or key=value parameters (e.g. format=unix).
+//
+// Invalid options are ignored, the first one is reported as a [*TagOptionError].
+func ParseTag(tag string) (Tag, error) {
+	key, opts, _ := strings.Cut(tag, ",")
+	t := Tag{Key: key}
+
+	var err error
+	for opts != "" {
+		var opt string
+		opt, opts, _ = strings.Cut(opts, ",")
+
+		if optErr := t.setOption(opt); optErr != nil && err == nil {
+			err = &TagOptionError{Option: opt, Err: optErr}
+		}
+	}
+
+	return t, err
+}
+
+// setOption sets a single option of t.
+func (t *Tag) setOption(opt string) error {
+	name, value, hasValue := strings.Cut(opt, "=")
+	if name == "" {
+		return ErrMalformedOption
+	}
+
+	if set, ok := flags[name]; ok {
+		if hasValue {
+			return ErrMalformedOption
+		}
+		set(t)
+		return nil
+	}
+
+	valid, ok := params[name]
+	if !ok {
+		return ErrUnknownOption
+	}
+	if value == "" || valid != nil && !valid(value) {
+		return ErrMalformedOption
+	}
+
+	if name == paramProp {
+		t.Props = append(t.Props, value)
+		return nil
+	}
+
+	if t.Params == nil {
+		t.Params = make(map[string]string)
+	}
+	t.Params[name] = value
+	return nil
+}
+
+// Max returns the maximum number of characters of strings, or elements of slices, 0 if unlimited.
+func (t Tag) Max() int {
+	n, _ := strconv.Atoi(t.Params[paramMax])
+	return n
+}
+
+// Priority returns the priority of the baggage members of the field, 0 by default.
+func (t Tag) Priority() int {
+	n, _ := strconv.Atoi(t.Params[paramPriority])
+	return n
+}
+
+// SliceSeparator returns the separator joining the elements of slices in baggage values.
+func (t Tag) SliceSeparator() string {
+	if t.Separator == "" {
+		return DefaultSliceSeparator
+	}
+	return t.Separator
+}
@@ -0,0 +1,162 @@
+package internal
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var (
+	stringerType        = reflect.TypeFor[fmt.Stringer]()
+	textMarshalerType   = reflect.TypeFor[encoding.TextMarshaler]()
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+)
+
+// Implements reports whether t or *t implements the provided interface type.
+func Implements(t, iface reflect.Type) bool {
+	return t.Implements(iface) || t.Kind() != reflect.Pointer && reflect.PointerTo(t).Implements(iface)
+}
+
+// isText reports whether values of the provided type are converted to strings through
+// [encoding.TextMarshaler] or [fmt.Stringer].
+// [time.Duration] values are numbers rather than their String representation.
+func isText(t reflect.Type, tag Tag) bool {
+	return !tag.Raw && t != durationType && (Implements(t, textMarshalerType) || Implements(t, stringerType))
+}
+
+// textFormatter returns a function formatting values of the provided type through [encoding.TextMarshaler],
+// or [fmt.Stringer] otherwise, on value or pointer receivers.
+// Nil values, including nil pointers held by interfaces, are formatted as empty strings.
+// The function returns false if the value cannot be formatted. Returns nil if t implements neither.
+func textFormatter(t reflect.Type) func(fieldValue reflect.Value) (string, bool) {
+	switch {
+	case Implements(t, textMarshalerType):
+		return func(fieldValue reflect.Value) (string, bool) {
+			if IsNil(fieldValue) {
+				return "", true
+			}
+			m, ok := MethodReceiver[encoding.TextMarshaler](fieldValue)
+			if !ok {
+				return "", false
+			}
+			b, err := m.MarshalText()
+			if err != nil {
+				return "", false
+			}
+			return string(b), true
+		}
+	case Implements(t, stringerType):
+		return func(fieldValue reflect.Value) (string, bool) {
+			if IsNil(fieldValue) {
+				return "", true
+			}
+			s, ok := MethodReceiver[fmt.Stringer](fieldValue)
+			if !ok {
+				return "", false
+			}
+			return s.String(), true
+		}
+	}
+
+	return nil
+}
+
+// textSpanAttribute returns the [SpanAttributeFunc] of types implementing [encoding.TextMarshaler] or [fmt.Stringer].
+func textSpanAttribute(t reflect.Type) SpanAttributeFunc {
+	format := textFormatter(t)
+	return func(attrKey attribute.Key, fieldValue reflect.Value) (attribute.KeyValue, bool) {
+		v, ok := format(fieldValue)
+		if !ok {
+			return attribute.KeyValue{}, true
+		}
+		return attrKey.String(v), fieldValue.IsZero() || IsNil(fieldValue)
+	}
+}
+
+// textBaggageValueFormatter returns the baggage value formatter of types implementing
+// [encoding.TextMarshaler] or [fmt.Stringer].
+func textBaggageValueFormatter(t reflect.Type) func(fieldValue reflect.Value) (string, bool) {
+	format := textFormatter(t)
+	return func(fieldValue reflect.Value) (string, bool) {
+		v, ok := format(fieldValue)
+		if !ok {
+			return "", true
+		}
+		return v, fieldValue.IsZero() || IsNil(fieldValue)
+	}
+}
+
+// textUnmarshaler returns a function setting values of the provided type through [encoding.TextUnmarshaler].
+// Returns nil if *t does not implement it.
+func textUnmarshaler(t reflect.Type) func(text string, fieldValue reflect.Value) error {
+	if !reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return nil
+	}
+
+	return func(text string, fieldValue reflect.Value) error {
+		v := reflect.New(t)
+		if err := v.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(text)); err != nil {
+			return err
+		}
+		fieldValue.Set(v.Elem())
+		return nil
+	}
+}
+
+// textAttributeValueSetter returns the [AttributeValueSetFunc] of types implementing [encoding.TextUnmarshaler].
+// Returns nil if *t does not implement it.
+func textAttributeValueSetter(t reflect.Type) AttributeValueSetFunc {
+	unmarshal := textUnmarshaler(t)
+	if unmarshal == nil {
+		return nil
+	}
+
+	return func(attrValue attribute.Value, fieldValue reflect.Value) error {
+		if attrValue.Type() != attribute.STRING {
+			return ErrAttributeTypeMismatch
+		}
+		return unmarshal(attrValue.AsString(), fieldValue)
+	}
+}
+
+// MethodReceiver returns v, or a pointer to v (or to a copy of v if it is not addressable), as a T.
+// Returns false if v is nil (see [IsNil]) or cannot be used through Interface, i.e. if it was obtained
+// through an unexported field.
+func MethodReceiver[T any](v reflect.Value) (T, bool) {
+	if IsNil(v) {
+		var zero T
+		return zero, false
+	}
+
+	if v.Type().Implements(reflect.TypeFor[T]()) {
+		return InterfaceOf[T](v)
+	}
+
+	if v.CanAddr() {
+		return InterfaceOf[T](v.Addr())
+	}
+
+	if !v.CanInterface() {
+		var zero T
+		return zero, false
+	}
+
+	p := reflect.New(v.Type())
+	p.Elem().Set(v)
+	return p.Interface().(T), true
+}
+
+// IsNil reports whether v is a nil pointer or interface, or an interface holding a nil pointer:
+// methods cannot be safely called on such values.
+func IsNil(v reflect.Value) bool {
+	if v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return true
+		}
+		v = v.Elem()
+	}
+
+	return v.Kind() == reflect.Pointer && v.IsNil()
+}
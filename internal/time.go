@@ -0,0 +1,262 @@
+package internal
+
+import (
+	"math"
+	"reflect"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var (
+	timeType     = reflect.TypeFor[time.Time]()
+	durationType = reflect.TypeFor[time.Duration]()
+)
+
+// IsValueStruct reports whether values of the provided struct type (or pointer to a struct type),
+// tagged with the provided tag, are converted as a whole (e.g. [time.Time], a [fmt.Stringer] or a type with
+// a registered converter) instead of having their fields walked.
+func IsValueStruct(t reflect.Type, tag Tag, conv Converters) bool {
+	if hasConverter(conv, t) {
+		return true
+	}
+
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+		if hasConverter(conv, t) {
+			return true
+		}
+	}
+
+	return t == timeType || isText(t, tag)
+}
+
+// timeCodec converts [time.Time] values from and to the representation selected by the format tag option:
+// unix, unixmilli, unixmicro, unixnano or a layout (RFC 3339 with nanoseconds by default).
+type timeCodec struct {
+	layout   string
+	toUnix   func(time.Time) int64 // Set if the values are represented as unix timestamps.
+	fromUnix func(int64) time.Time
+}
+
+// newTimeCodec returns the [timeCodec] of the provided tag.
+func newTimeCodec(tag Tag) timeCodec {
+	switch format := tag.Params[paramFormat]; format {
+	case "":
+		return timeCodec{layout: time.RFC3339Nano}
+	case "unix":
+		return timeCodec{toUnix: time.Time.Unix, fromUnix: func(v int64) time.Time { return time.Unix(v, 0) }}
+	case "unixmilli":
+		return timeCodec{toUnix: time.Time.UnixMilli, fromUnix: time.UnixMilli}
+	case "unixmicro":
+		return timeCodec{toUnix: time.Time.UnixMicro, fromUnix: time.UnixMicro}
+	case "unixnano":
+		return timeCodec{toUnix: time.Time.UnixNano, fromUnix: func(v int64) time.Time { return time.Unix(0, v) }}
+	default:
+		return timeCodec{layout: format}
+	}
+}
+
+func (c timeCodec) attribute(attrKey attribute.Key, t time.Time) attribute.KeyValue {
+	if c.toUnix != nil {
+		return attrKey.Int64(c.toUnix(t))
+	}
+	return attrKey.String(t.Format(c.layout))
+}
+
+func (c timeCodec) format(t time.Time) string {
+	if c.toUnix != nil {
+		return strconv.FormatInt(c.toUnix(t), 10)
+	}
+	return t.Format(c.layout)
+}
+
+func (c timeCodec) parse(s string) (time.Time, error) {
+	if c.toUnix != nil {
+		v, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return c.fromUnix(v), nil
+	}
+	return time.Parse(c.layout, s)
+}
+
+func (c timeCodec) fromAttribute(attrValue attribute.Value) (time.Time, error) {
+	if c.toUnix != nil {
+		if attrValue.Type() != attribute.INT64 {
+			return time.Time{}, ErrAttributeTypeMismatch
+		}
+		return c.fromUnix(attrValue.AsInt64()), nil
+	}
+
+	if attrValue.Type() != attribute.STRING {
+		return time.Time{}, ErrAttributeTypeMismatch
+	}
+	return time.Parse(c.layout, attrValue.AsString())
+}
+
+// durationCodec converts [time.Duration] values from and to the unit selected by the unit tag option:
+// ns (default), us or ms as integers, s as a float.
+type durationCodec struct {
+	unit    time.Duration
+	seconds bool
+}
+
+// newDurationCodec returns the [durationCodec] of the provided tag.
+func newDurationCodec(tag Tag) durationCodec {
+	switch tag.Params[paramUnit] {
+	case "us":
+		return durationCodec{unit: time.Microsecond}
+	case "ms":
+		return durationCodec{unit: time.Millisecond}
+	case "s":
+		return durationCodec{unit: time.Second, seconds: true}
+	default:
+		return durationCodec{unit: time.Nanosecond}
+	}
+}
+
+func (c durationCodec) attribute(attrKey attribute.Key, d time.Duration) attribute.KeyValue {
+	if c.seconds {
+		return attrKey.Float64(d.Seconds())
+	}
+	return attrKey.Int64(int64(d / c.unit))
+}
+
+func (c durationCodec) format(d time.Duration) string {
+	if c.seconds {
+		return strconv.FormatFloat(d.Seconds(), 'f', -1, 64)
+	}
+	return strconv.FormatInt(int64(d/c.unit), 10)
+}
+
+func (c durationCodec) parse(s string) (time.Duration, error) {
+	if c.seconds {
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0, err
+		}
+		return secondsToDuration(v), nil
+	}
+
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(v) * c.unit, nil
+}
+
+func (c durationCodec) fromAttribute(attrValue attribute.Value) (time.Duration, error) {
+	if c.seconds {
+		if attrValue.Type() != attribute.FLOAT64 {
+			return 0, ErrAttributeTypeMismatch
+		}
+		return secondsToDuration(attrValue.AsFloat64()), nil
+	}
+
+	if attrValue.Type() != attribute.INT64 {
+		return 0, ErrAttributeTypeMismatch
+	}
+	return time.Duration(attrValue.AsInt64()) * c.unit, nil
+}
+
+// secondsToDuration converts a number of seconds to a [time.Duration], rounded to the nanosecond.
+func secondsToDuration(v float64) time.Duration {
+	return time.Duration(math.Round(v * float64(time.Second)))
+}
+
+// timeSpanAttribute returns the [SpanAttributeFunc] of [time.Time] values.
+func timeSpanAttribute(tag Tag) SpanAttributeFunc {
+	codec := newTimeCodec(tag)
+	return func(attrKey attribute.Key, fieldValue reflect.Value) (attribute.KeyValue, bool) {
+		t, ok := InterfaceOf[time.Time](fieldValue)
+		if !ok {
+			return attribute.KeyValue{}, true
+		}
+		return codec.attribute(attrKey, t), t.IsZero()
+	}
+}
+
+// durationSpanAttribute returns the [SpanAttributeFunc] of [time.Duration] values.
+func durationSpanAttribute(tag Tag) SpanAttributeFunc {
+	codec := newDurationCodec(tag)
+	return func(attrKey attribute.Key, fieldValue reflect.Value) (attribute.KeyValue, bool) {
+		d := time.Duration(fieldValue.Int())
+		return codec.attribute(attrKey, d), d == 0
+	}
+}
+
+// timeBaggageValueFormatter returns the baggage value formatter of [time.Time] values.
+func timeBaggageValueFormatter(tag Tag) func(fieldValue reflect.Value) (string, bool) {
+	codec := newTimeCodec(tag)
+	return func(fieldValue reflect.Value) (string, bool) {
+		t, ok := InterfaceOf[time.Time](fieldValue)
+		if !ok {
+			return "", true
+		}
+		return codec.format(t), t.IsZero()
+	}
+}
+
+// durationBaggageValueFormatter returns the baggage value formatter of [time.Duration] values.
+func durationBaggageValueFormatter(tag Tag) func(fieldValue reflect.Value) (string, bool) {
+	codec := newDurationCodec(tag)
+	return func(fieldValue reflect.Value) (string, bool) {
+		d := time.Duration(fieldValue.Int())
+		return codec.format(d), d == 0
+	}
+}
+
+// timeBaggageValueParser returns the [BaggageValueParseFunc] of [time.Time] values.
+func timeBaggageValueParser(tag Tag) BaggageValueParseFunc {
+	codec := newTimeCodec(tag)
+	return func(memberValue string, fieldValue reflect.Value) error {
+		t, err := codec.parse(memberValue)
+		if err != nil {
+			return err
+		}
+		fieldValue.Set(reflect.ValueOf(t))
+		return nil
+	}
+}
+
+// durationBaggageValueParser returns the [BaggageValueParseFunc] of [time.Duration] values.
+func durationBaggageValueParser(tag Tag) BaggageValueParseFunc {
+	codec := newDurationCodec(tag)
+	return func(memberValue string, fieldValue reflect.Value) error {
+		d, err := codec.parse(memberValue)
+		if err != nil {
+			return err
+		}
+		fieldValue.SetInt(int64(d))
+		return nil
+	}
+}
+
+// timeAttributeValueSetter returns the [AttributeValueSetFunc] of [time.Time] values.
+func timeAttributeValueSetter(tag Tag) AttributeValueSetFunc {
+	codec := newTimeCodec(tag)
+	return func(attrValue attribute.Value, fieldValue reflect.Value) error {
+		t, err := codec.fromAttribute(attrValue)
+		if err != nil {
+			return err
+		}
+		fieldValue.Set(reflect.ValueOf(t))
+		return nil
+	}
+}
+
+// durationAttributeValueSetter returns the [AttributeValueSetFunc] of [time.Duration] values.
+func durationAttributeValueSetter(tag Tag) AttributeValueSetFunc {
+	codec := newDurationCodec(tag)
+	return func(attrValue attribute.Value, fieldValue reflect.Value) error {
+		d, err := codec.fromAttribute(attrValue)
+		if err != nil {
+			return err
+		}
+		fieldValue.SetInt(int64(d))
+		return nil
+	}
+}
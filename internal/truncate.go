@@ -0,0 +1,112 @@
+package internal
+
+import (
+	"reflect"
+	"strings"
+	"unicode/utf8"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// IsTruncatable reports whether values of the provided type are strings or slices, whose length can be limited.
+// Values converted by conv, as well as times and durations, are never truncated.
+func IsTruncatable(t reflect.Type, tag Tag, conv Converters) bool {
+	converted := func(t reflect.Type) bool {
+		return conv != nil && (conv.SpanConverter(t) != nil || conv.BaggageConverter(t) != nil)
+	}
+
+	if converted(t) {
+		return false
+	}
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+		if converted(t) {
+			return false
+		}
+	}
+
+	if t == timeType || t == durationType {
+		return false
+	}
+
+	return isText(t, tag) || t.Kind() == reflect.String || t.Kind() == reflect.Slice
+}
+
+// TruncateAttribute truncates a string attribute to max characters and caps a slice attribute to max elements.
+// Also returns the original length of a truncated attribute value, -1 if it was not truncated.
+func TruncateAttribute(attr attribute.KeyValue, max int) (attribute.KeyValue, int) {
+	switch attr.Value.Type() {
+	case attribute.STRING:
+		v, n := truncateString(attr.Value.AsString(), max)
+		if n < 0 {
+			return attr, n
+		}
+		return attr.Key.String(v), n
+	case attribute.BOOLSLICE:
+		return truncateSlice(attr, attr.Value.AsBoolSlice(), max, attr.Key.BoolSlice)
+	case attribute.INT64SLICE:
+		return truncateSlice(attr, attr.Value.AsInt64Slice(), max, attr.Key.Int64Slice)
+	case attribute.FLOAT64SLICE:
+		return truncateSlice(attr, attr.Value.AsFloat64Slice(), max, attr.Key.Float64Slice)
+	case attribute.STRINGSLICE:
+		return truncateSlice(attr, attr.Value.AsStringSlice(), max, attr.Key.StringSlice)
+	default:
+		return attr, -1
+	}
+}
+
+func truncateSlice[T any](attr attribute.KeyValue, s []T, max int, build func([]T) attribute.KeyValue) (attribute.KeyValue, int) {
+	if len(s) <= max {
+		return attr, -1
+	}
+	return build(s[:max]), len(s)
+}
+
+// TruncateBaggageValue truncates a baggage value to max characters, or to max elements if it holds a slice
+// whose elements are joined with sep. Also returns the original length of a truncated value,
+// -1 if it was not truncated.
+func TruncateBaggageValue(v string, max int, slice bool, sep string) (string, int) {
+	if !slice {
+		return truncateString(v, max)
+	}
+	if v == "" {
+		return v, -1
+	}
+
+	n := strings.Count(v, sep) + 1
+	if n <= max {
+		return v, -1
+	}
+
+	end := 0
+	for i := 0; i < max; i++ {
+		end += strings.Index(v[end:], sep) + len(sep)
+	}
+	if end == len(sep) {
+		// Kept a single empty element, which must not be mistaken for an empty slice.
+		return emptyElem, n
+	}
+	return v[:end-len(sep)], n
+}
+
+// truncateString truncates v to max characters, without splitting multi-byte characters.
+// Also returns the original number of characters of a truncated v, -1 if it was not truncated.
+func truncateString(v string, max int) (string, int) {
+	if len(v) <= max {
+		return v, -1
+	}
+
+	n := utf8.RuneCountInString(v)
+	if n <= max {
+		return v, -1
+	}
+
+	end, i := 0, 0
+	for end = range v {
+		if i == max {
+			break
+		}
+		i++
+	}
+	return v[:end], n
+}
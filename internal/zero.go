@@ -0,0 +1,31 @@
+package internal
+
+import "reflect"
+
+// isZeroer is implemented by types reporting whether they hold their zero value, such as [time.Time].
+type isZeroer interface {
+	IsZero() bool
+}
+
+var isZeroerType = reflect.TypeFor[isZeroer]()
+
+// ZeroChecker returns a function reporting whether values of the provided type are zero, through their
+// IsZero method (on a value or pointer receiver) if any, [reflect.Value.IsZero] otherwise.
+// Nil pointers are always zero.
+func ZeroChecker(t reflect.Type) func(fieldValue reflect.Value) bool {
+	if !Implements(t, isZeroerType) {
+		return reflect.Value.IsZero
+	}
+
+	return func(fieldValue reflect.Value) bool {
+		if fieldValue.Kind() == reflect.Pointer && fieldValue.IsNil() {
+			return true
+		}
+
+		zeroer, ok := MethodReceiver[isZeroer](fieldValue)
+		if !ok {
+			return fieldValue.IsZero()
+		}
+		return zeroer.IsZero()
+	}
+}
@@ -1,3 +0,0 @@
-package oteltag
-
-const flagOmitEmpty = "omitempty"
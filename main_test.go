@@ -1,5 +1,19 @@
 package oteltag_test
 
+import (
+	"encoding/hex"
+	"net"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+)
+
 type testModel struct {
 	ValStr          string    `otel:"val_str,omitempty"`
 	ValInt          int       `otel:"val_int,omitempty"`
@@ -12,3 +26,244 @@ type testModel struct {
 	ValFloat64Slice []float64 `otel:"val_float64_slice,omitempty"`
 	ValBoolSlice    []bool    `otel:"val_bool_slice,omitempty"`
 }
+
+type numericTestModel struct {
+	ValInt8         int8      `otel:"val_int8"`
+	ValInt16        int16     `otel:"val_int16"`
+	ValInt32        int32     `otel:"val_int32"`
+	ValUint         uint      `otel:"val_uint"`
+	ValUint8        uint8     `otel:"val_uint8"`
+	ValUint16       uint16    `otel:"val_uint16"`
+	ValUint32       uint32    `otel:"val_uint32"`
+	ValUint64       uint64    `otel:"val_uint64"`
+	ValUint64Max    uint64    `otel:"val_uint64_max"`
+	ValFloat32      float32   `otel:"val_float32"`
+	ValInt32Slice   []int32   `otel:"val_int32_slice"`
+	ValUint16Slice  []uint16  `otel:"val_uint16_slice"`
+	ValUint64Slice  []uint64  `otel:"val_uint64_slice"`
+	ValFloat32Slice []float32 `otel:"val_float32_slice"`
+}
+
+type (
+	testRole   string
+	testLevel  int
+	testRatio  float64
+	testFlag   bool
+	testRoles  []testRole
+	testLevels []testLevel
+)
+
+type namedTestModel struct {
+	Role   testRole    `otel:"role"`
+	Roles  testRoles   `otel:"roles"`
+	Levels testLevels  `otel:"levels"`
+	Ratios []testRatio `otel:"ratios"`
+	Flags  []testFlag  `otel:"flags"`
+}
+
+type testBase struct {
+	ID string `otel:"base.id"`
+}
+
+type unexportedTestModel struct {
+	sync.Mutex
+	testBase
+
+	Counter atomic.Int64
+	details struct {
+		Bio string `otel:"details.bio"`
+	}
+	tags []string `otel:"tags"`
+	Name string   `otel:"name"`
+}
+
+func newUnexportedTestModel() *unexportedTestModel {
+	m := &unexportedTestModel{
+		testBase: testBase{ID: "123"},
+		tags:     []string{"a", "b"},
+		Name:     "john_doe",
+	}
+	m.Counter.Store(42)
+	m.details.Bio = "dev"
+
+	return m
+}
+
+type timeTestModel struct {
+	CreatedAt     time.Time     `otel:"created_at"`
+	CreatedAtUnix time.Time     `otel:"created_at_unix,format=unix"`
+	CreatedAtMs   time.Time     `otel:"created_at_ms,format=unixmilli"`
+	CreatedOn     *time.Time    `otel:"created_on,format=2006-01-02"`
+	UpdatedAt     time.Time     `otel:"updated_at,omitempty"`
+	Timeout       time.Duration `otel:"timeout"`
+	TimeoutMs     time.Duration `otel:"timeout_ms,unit=ms"`
+	TimeoutS      time.Duration `otel:"timeout_s,unit=s"`
+}
+
+// equalTimeTestModels reports whether a and b hold the same instants and durations,
+// regardless of their locations.
+func equalTimeTestModels(a, b timeTestModel) bool {
+	return a.CreatedAt.Equal(b.CreatedAt) &&
+		a.CreatedAtUnix.Equal(b.CreatedAtUnix) &&
+		a.CreatedAtMs.Equal(b.CreatedAtMs) &&
+		a.CreatedOn != nil && b.CreatedOn != nil && a.CreatedOn.Equal(*b.CreatedOn) &&
+		a.UpdatedAt.Equal(b.UpdatedAt) &&
+		a.Timeout == b.Timeout &&
+		a.TimeoutMs == b.TimeoutMs &&
+		a.TimeoutS == b.TimeoutS
+}
+
+type testStatus int
+
+func (s testStatus) String() string {
+	switch s {
+	case 1:
+		return "active"
+	case 2:
+		return "suspended"
+	default:
+		return "unknown"
+	}
+}
+
+type testID [4]byte
+
+func (id testID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+type textTestModel struct {
+	Status    testStatus   `otel:"status"`
+	StatusRaw testStatus   `otel:"status_raw,raw"`
+	Statuses  []testStatus `otel:"statuses"`
+	ID        testID       `otel:"id"`
+	IP        net.IP       `otel:"ip"`
+	Addr      netip.Addr   `otel:"addr"`
+	URL       url.URL      `otel:"url"`
+	NilURL    *url.URL     `otel:"nil_url,omitempty"`
+}
+
+type testCart struct {
+	ID    string  `otel:"cart.id"`
+	Items []int64 `otel:"cart.items"`
+}
+
+func (c *testCart) OtelAttributes() []attribute.KeyValue {
+	return []attribute.KeyValue{attribute.Int("cart.size", len(c.Items))}
+}
+
+func (c *testCart) OtelBaggage() []baggage.Member {
+	m, _ := baggage.NewMemberRaw("cart.size", strconv.Itoa(len(c.Items)))
+	return []baggage.Member{m}
+}
+
+type customTestModel struct {
+	Cart        testCart `otel:",merge"`
+	ReplaceCart *testCart
+	NilCart     *testCart
+	Name        string `otel:"name"`
+}
+
+type testGeo struct {
+	Country string `otel:"country"`
+}
+
+type testAddress struct {
+	City string  `otel:"city"`
+	Geo  testGeo `otel:"geo,prefix"`
+}
+
+type prefixTestModel struct {
+	ID       string       `otel:"id"`
+	Home     testAddress  `otel:"app.home,prefix"`
+	Work     *testAddress `otel:"app.work,prefix"`
+	Shipping testAddress
+}
+
+func newPrefixTestModel() prefixTestModel {
+	return prefixTestModel{
+		ID:       "42",
+		Home:     testAddress{City: "Paris", Geo: testGeo{Country: "FR"}},
+		Work:     &testAddress{City: "Lyon", Geo: testGeo{Country: "FR"}},
+		Shipping: testAddress{City: "Nice", Geo: testGeo{Country: "FR"}},
+	}
+}
+
+// testOrderID reports its own zero value: IDs below 1 are unset.
+type testOrderID int
+
+func (id testOrderID) IsZero() bool {
+	return id < 1
+}
+
+type testShipment struct {
+	Carrier string `otel:"shipment.carrier"`
+	Weight  int    `otel:"shipment.weight"`
+}
+
+type zeroTestModel struct {
+	ID        testOrderID   `otel:"order.id,omitzero"`
+	Note      string        `otel:"order.note,omitzero"`
+	Tags      []string      `otel:"order.tags,omitzero"`
+	CreatedAt time.Time     `otel:"order.created_at,omitzero"`
+	Shipment  testShipment  `otel:",omitzero"`
+	Return    *testShipment `otel:",omitzero"`
+	Count     int           `otel:"order.count"`
+}
+
+type redactTestModel struct {
+	Email    string   `otel:"user.email,redact"`
+	Age      int      `otel:"user.age,redact"`
+	Card     string   `otel:"user.card,mask=last4"`
+	Phone    string   `otel:"user.phone,mask=first3"`
+	PIN      string   `otel:"user.pin,mask=last4"`
+	Tokens   []string `otel:"user.tokens,mask=last2"`
+	Username string   `otel:"user.username"`
+}
+
+func newRedactTestModel() redactTestModel {
+	return redactTestModel{
+		Email:    "john@example.com",
+		Age:      42,
+		Card:     "4111111111111111",
+		Phone:    "0612345678",
+		PIN:      "1234",
+		Tokens:   []string{"abcd", "éfgh"},
+		Username: "john_doe",
+	}
+}
+
+type truncateTestModel struct {
+	Description string   `otel:"order.description,max=5"`
+	Note        string   `otel:"order.note,max=5"`
+	Tags        []string `otel:"order.tags,max=2"`
+	IDs         []int    `otel:"order.ids,max=2"`
+	Title       string   `otel:"order.title"`
+}
+
+func newTruncateTestModel() truncateTestModel {
+	return truncateTestModel{
+		Description: "crème brûlée",
+		Note:        "short",
+		Tags:        []string{"a", "b", "c"},
+		IDs:         []int{1, 2},
+		Title:       "a long title",
+	}
+}
+
+type testRegion string
+
+func (r testRegion) OtelBaggageProperties() []baggage.Property {
+	p, _ := baggage.NewKeyValueProperty("residency", string(r))
+	return []baggage.Property{p}
+}
+
+// countingAttributer counts the calls to OtelAttributes.
+type countingAttributer struct {
+	calls *int
+}
+
+func (a countingAttributer) OtelAttributes() []attribute.KeyValue {
+	*a.calls++
+	return nil
+}
@@ -0,0 +1,65 @@
+package oteltag
+
+import (
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// KeyNaming derives the key of an untagged field, see [WithAutoKeys].
+type KeyNaming func(field reflect.StructField) string
+
+// SnakeCase derives keys in snake_case from the field names (e.g. UserID becomes "user_id").
+func SnakeCase(field reflect.StructField) string {
+	return strings.Join(splitWords(field.Name), "_")
+}
+
+// DotCase derives keys in dot.case from the field names (e.g. UserID becomes "user.id").
+func DotCase(field reflect.StructField) string {
+	return strings.Join(splitWords(field.Name), ".")
+}
+
+// JSONName derives keys from the names of the json tags, falling back to fallback ([SnakeCase] if nil)
+// for fields without one. Fields tagged with `json:"-"` are ignored.
+func JSONName(fallback KeyNaming) KeyNaming {
+	if fallback == nil {
+		fallback = SnakeCase
+	}
+
+	return func(field reflect.StructField) string {
+		tag, ok := field.Tag.Lookup("json")
+		if tag == "-" {
+			return ""
+		}
+
+		if name, _, _ := strings.Cut(tag, ","); ok && name != "" {
+			return name
+		}
+
+		return fallback(field)
+	}
+}
+
+// splitWords splits a Go identifier into lowercase words at underscores and case changes,
+// keeping acronyms together (e.g. HTTPServerID becomes "http", "server" and "id").
+func splitWords(name string) []string {
+	var words []string
+	for _, part := range strings.Split(name, "_") {
+		runes := []rune(part)
+		start := 0
+		for i := 1; i < len(runes); i++ {
+			prev, cur := runes[i-1], runes[i]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsUpper(cur) && (!unicode.IsUpper(prev) || nextLower) {
+				words = append(words, strings.ToLower(string(runes[start:i])))
+				start = i
+			}
+		}
+
+		if start < len(runes) {
+			words = append(words, strings.ToLower(string(runes[start:])))
+		}
+	}
+
+	return words
+}
@@ -0,0 +1,228 @@
+package oteltag
+
+import (
+	"crypto/sha256"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Option configures an [Encoder].
+type Option func(*Encoder)
+
+// WithUnexportedFields extracts tagged unexported fields (and the fields of unexported struct fields)
+// like exported ones. They are ignored by default.
+//
+// Unexported fields are read through reflection only, they are never decoded into.
+func WithUnexportedFields() Option {
+	return func(e *Encoder) {
+		e.unexportedFields = true
+	}
+}
+
+// WithRootMerge extracts both the custom and the tag-based values of top-level values implementing
+// [Attributer] or [Baggager], like the merge tag option does for fields. Their custom values replace
+// the tag-based ones by default.
+func WithRootMerge() Option {
+	return func(e *Encoder) {
+		e.rootMerge = true
+	}
+}
+
+// WithStrictKeys makes [Encoder.UnmarshalAttributes] and [Encoder.UnmarshalSpan] return a [*KeyMismatchError], once all fields
+// are set, if some attributes have no matching field or some fields not tagged with omitempty or omitzero
+// have no matching attribute.
+func WithStrictKeys() Option {
+	return func(e *Encoder) {
+		e.strictKeys = true
+	}
+}
+
+// WithConverters consults the converters registered in c before the ones of the package default registry.
+func WithConverters(c *Converters) Option {
+	return func(e *Encoder) {
+		if c != nil {
+			e.converters = c
+		}
+	}
+}
+
+// WithTagName reads the keys and options from the provided struct tag instead of `otel`.
+func WithTagName(name string) Option {
+	return func(e *Encoder) {
+		if name != "" {
+			e.tagName = name
+		}
+	}
+}
+
+// WithKeyPrefix prepends prefix to the keys of all tagged fields, e.g. "app." turns `otel:"user.id"` into
+// the "app.user.id" key. Values provided by [Attributer] and [Baggager] implementations are left untouched.
+func WithKeyPrefix(prefix string) Option {
+	return func(e *Encoder) {
+		e.keyPrefix = prefix
+	}
+}
+
+// WithSliceSeparator joins the elements of slices in baggage values with sep instead of a comma.
+// Separators containing "%" or hexadecimal digits would clash with the escaping of elements, they are ignored.
+func WithSliceSeparator(sep string) Option {
+	return func(e *Encoder) {
+		if sep != "" && !strings.ContainsAny(sep, "%0123456789ABCDEFabcdef") {
+			e.sliceSeparator = sep
+		}
+	}
+}
+
+// WithOmitEmpty treats all tagged fields as if they were tagged with omitempty.
+func WithOmitEmpty() Option {
+	return func(e *Encoder) {
+		e.omitEmpty = true
+	}
+}
+
+// WithMaxDepth limits the walk to depth levels of nested structs below the top-level struct:
+// fields of deeper structs are ignored. A depth of 0 only extracts the fields of the top-level struct.
+// A negative depth, the default, does not limit the walk.
+//
+// Embedded structs count as a level like any other nested struct.
+func WithMaxDepth(depth int) Option {
+	return func(e *Encoder) {
+		e.maxDepth = depth
+	}
+}
+
+// WithAutoKeys extracts untagged exported fields too, deriving their keys from the fields with naming
+// ([SnakeCase] if nil). Fields tagged with an empty key (e.g. `otel:",omitempty"`) get a derived key as well.
+// Fields for which naming returns an empty key are ignored, like fields tagged with "-".
+//
+// Untagged struct fields are still walked, unless they are converted as a whole (e.g. [time.Time]).
+func WithAutoKeys(naming KeyNaming) Option {
+	return func(e *Encoder) {
+		if naming == nil {
+			naming = SnakeCase
+		}
+		e.keyNaming = naming
+	}
+}
+
+// WithoutRedaction extracts the values of the fields tagged with redact or mask as is,
+// which comes in handy in local or development environments. Fields tagged with hash are still hashed.
+func WithoutRedaction() Option {
+	return func(e *Encoder) {
+		e.noRedaction = true
+	}
+}
+
+// HashEncoding selects how hashes are encoded, see [WithHashFormat].
+type HashEncoding int
+
+const (
+	HashHex    HashEncoding = iota // Lowercase hex.
+	HashBase64                     // URL-safe base64 without padding.
+)
+
+// WithHashKey hashes the values of the fields tagged with hash with HMAC-SHA256 and the provided secret.
+// Without it, fields tagged with hash are never extracted.
+//
+// A non-empty keyID is extracted along each hashed value, under the "<key>.key_id" key,
+// so that consumers can tell which secret produced a hash when rotating secrets.
+func WithHashKey(keyID string, secret []byte) Option {
+	return func(e *Encoder) {
+		e.hashKeyID = keyID
+		e.hasher.Secret = append([]byte{}, secret...)
+	}
+}
+
+// WithHashFormat keeps the first size bytes of the hashes (1 to 32, 16 by default) and encodes them with enc.
+// Out of range sizes are ignored.
+func WithHashFormat(size int, enc HashEncoding) Option {
+	return func(e *Encoder) {
+		if size >= 1 && size <= sha256.Size {
+			e.hasher.Size = size
+		}
+		e.hasher.Base64 = enc == HashBase64
+	}
+}
+
+// WithMaxLength truncates strings to n characters and caps slices to n elements, for the fields
+// without a max tag option (e.g. `otel:"app.order.description,max=256"`). A zero n does not limit lengths.
+// Other values, such as numbers or times, are never truncated.
+//
+// Values are truncated last, after redaction.
+func WithMaxLength(n int) Option {
+	return func(e *Encoder) {
+		if n >= 0 {
+			e.maxLength = n
+		}
+	}
+}
+
+// TruncationMarker selects the companion value extracted along a truncated value, see [WithTruncationMarker].
+type TruncationMarker int
+
+const (
+	TruncationNone           TruncationMarker = iota // No companion value.
+	TruncationFlag                                   // "<key>.truncated": true.
+	TruncationOriginalLength                         // "<key>.original_length": the number of characters or elements.
+)
+
+// key returns the key of the companion value of the provided key, empty if none.
+func (m TruncationMarker) key(key string) attribute.Key {
+	switch m {
+	case TruncationFlag:
+		return attribute.Key(key + ".truncated")
+	case TruncationOriginalLength:
+		return attribute.Key(key + ".original_length")
+	default:
+		return ""
+	}
+}
+
+// value returns the companion value of a value of the provided original length.
+func (m TruncationMarker) value(originalLength int) attribute.Value {
+	if m == TruncationFlag {
+		return attribute.BoolValue(true)
+	}
+	return attribute.IntValue(originalLength)
+}
+
+// WithTruncationMarker extracts a companion value along each truncated value,
+// so that consumers know the value was cut.
+func WithTruncationMarker(m TruncationMarker) Option {
+	return func(e *Encoder) {
+		e.truncationMarker = m
+	}
+}
+
+// WithBaggageLimits sets the budget of a [BaggageBuilder]: the maximum encoded size in bytes and
+// the maximum number of members. Values out of the W3C limits ([MaxBaggageBytes] and [MaxBaggageMembers])
+// are ignored.
+func WithBaggageLimits(maxBytes, maxMembers int) Option {
+	return func(e *Encoder) {
+		if maxBytes > 0 && maxBytes <= MaxBaggageBytes {
+			e.maxBaggageBytes = maxBytes
+		}
+		if maxMembers > 0 && maxMembers <= MaxBaggageMembers {
+			e.maxBaggageMembers = maxMembers
+		}
+	}
+}
+
+// MergePolicy selects how the members of a struct are merged with the baggage already carried
+// by a context, see [ContextWithBaggage].
+type MergePolicy int
+
+const (
+	MergeOverwrite    MergePolicy = iota // Members of the struct replace existing members with the same key.
+	MergeKeepExisting                    // Existing members are kept, members of the struct with the same key are ignored.
+	MergeError                           // Existing members with the same key and a different value are reported.
+)
+
+// WithMergePolicy sets the policy applied by [Encoder.ContextWithBaggage] and [Encoder.Start] to members
+// whose key is already in the context baggage, [MergeOverwrite] by default.
+func WithMergePolicy(p MergePolicy) Option {
+	return func(e *Encoder) {
+		e.mergePolicy = p
+	}
+}
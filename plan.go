@@ -0,0 +1,345 @@
+package oteltag
+
+import (
+	"reflect"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+
+	"github.com/remychantenay/otel-tag/internal"
+)
+
+// typePlan is the compiled extraction plan of a struct type.
+// It is built once per type and shared by span attributes and baggage members extraction.
+type typePlan struct {
+	fields []fieldPlan
+
+	// attributer and baggager report whether the struct type implements Attributer and Baggager.
+	attributer, baggager bool
+
+	// tagErr is the first invalid tag of the fields, including the ones of nested struct values.
+	tagErr *InvalidTagError
+
+	// generation is the converters generation the plan was built with.
+	generation uint64
+}
+
+// fieldPlan describes how a single field is extracted.
+// A field is either a tagged leaf (basic type or pointer to a basic type) or a node: a pointer to a struct
+// or a type implementing Attributer or Baggager, whose plan is resolved lazily.
+type fieldPlan struct {
+	index     []int  // Index path from the planned struct, going through nested struct values.
+	depth     int    // Number of nested struct values between the planned struct and the field.
+	name      string // Dotted path of the field from the planned struct (e.g. "Address.City"), used in errors.
+	typ       reflect.Type
+	key       string
+	attrKey   attribute.Key
+	omitEmpty bool
+
+	// redacted reports whether the field value is redacted or masked, and therefore cannot be decoded.
+	redacted bool
+
+	// keyIDAttr and keyIDMember hold the id of the key hashing the field value, set for fields tagged with hash.
+	keyIDAttr   attribute.KeyValue
+	keyIDMember baggage.Member
+
+	// max is the maximum number of characters of strings, or elements of slices, 0 if unlimited
+	// or if the field holds neither.
+	// truncatedKey is the key of the companion value extracted when a value is truncated, if any.
+	max          int
+	truncatedKey attribute.Key
+
+	// priority is the priority of the baggage members of the field, see [BaggageBuilder].
+	priority int
+
+	// baggageScope reports whether the field, or one of the struct fields leading to it, is tagged with baggage,
+	// see [Start].
+	baggageScope bool
+
+	// joined reports whether the field value is a slice whose elements are joined in baggage values.
+	joined bool
+
+	// isZero reports whether the field value is zero and must be skipped, set for fields tagged with omitzero.
+	isZero func(fieldValue reflect.Value) bool
+
+	// validMemberKey reports whether key can be used as a baggage member key.
+	validMemberKey bool
+
+	// unexported reports whether the field, or one of the struct fields leading to it, is unexported.
+	unexported bool
+
+	// node reports whether the field is a node rather than a leaf.
+	node bool
+
+	// elem is the struct type of a node whose fields are walked, held by value or through a pointer.
+	elem    reflect.Type
+	pointer bool
+
+	// prefix is the key prefix of the fields of the node struct.
+	prefix string
+
+	// attributer and baggager report whether the node implements Attributer and Baggager,
+	// merge whether its tag-based values are extracted too.
+	attributer, baggager, merge bool
+
+	span         internal.SpanAttributeFunc
+	baggage      internal.BaggageMemberFunc
+	parseBaggage internal.BaggageValueParseFunc
+	setAttribute internal.AttributeValueSetFunc
+}
+
+// hashKeyIDSuffix is appended to the key of hashed fields to extract the id of the hash key.
+const hashKeyIDSuffix = ".key_id"
+
+// planKey identifies a [typePlan]: the same struct type gets different keys under different prefixes.
+type planKey struct {
+	typ    reflect.Type
+	prefix string
+}
+
+// buildPlan compiles the [typePlan] of the provided struct type according to the options of e.
+// The keys of its fields are prefixed with prefix.
+func (e *Encoder) buildPlan(t reflect.Type, prefix string) *typePlan {
+	p := &typePlan{
+		attributer: internal.Implements(t, attributerType),
+		baggager:   internal.Implements(t, baggagerType),
+	}
+	e.appendFieldPlans(p, t, prefix, nil, "", false, false)
+	return p
+}
+
+// appendFieldPlans appends the plans of all fields of t to p.
+// Fields of nested struct values are flattened, unless tagged with omitzero, pointers to structs are kept
+// as lazy nodes.
+// Tagged fields of unsupported types are kept without converters so that they can be reported.
+//
+// Fields tagged with "-" are ignored, struct fields included.
+// Struct fields tagged with the prefix option prepend their key followed by a dot to the keys of their fields.
+//
+// Unexported fields are planned too but flagged as such, embedded structs are considered exported
+// regardless of their type name, like in encoding/json. Likewise, the fields of a struct tagged with baggage
+// are scoped to the baggage.
+func (e *Encoder) appendFieldPlans(p *typePlan, t reflect.Type, prefix string, parentIndex []int, parentName string, parentUnexported, parentScoped bool) {
+	conv := e.converters
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		index := append(append(make([]int, 0, len(parentIndex)+1), parentIndex...), i)
+		name := field.Name
+		if parentName != "" {
+			name = parentName + "." + name
+		}
+
+		// Untagged struct fields are always walked, tagged ones only if not converted as a whole.
+		// In auto-key mode, untagged exported fields are handled like tagged ones.
+		rawTag := internal.ExtractTag(field, e.tagName)
+		if rawTag == internal.IgnoreTag {
+			continue
+		}
+		tag, err := internal.ParseTag(rawTag)
+		if optErr, ok := err.(*internal.TagOptionError); ok && p.tagErr == nil {
+			p.tagErr = &InvalidTagError{Field: name, Tag: rawTag, Option: optErr.Option, Err: optErr.Err}
+		}
+		tag.Separator = e.sliceSeparator
+		if e.noRedaction {
+			tag = tag.WithoutRedaction()
+		}
+		if tag.Hash {
+			if len(e.hasher.Secret) == 0 {
+				// Never extract the raw value of a field meant to be hashed.
+				if p.tagErr == nil {
+					p.tagErr = &InvalidTagError{Field: name, Tag: rawTag, Option: "hash", Err: ErrMissingHashKey}
+				}
+				continue
+			}
+			tag.Hasher = &e.hasher
+		}
+		autoKey := e.keyNaming != nil && field.IsExported()
+		if tag.Key == "" && autoKey {
+			tag.Key = e.keyNaming(field)
+		}
+		tagged := rawTag != "" || autoKey && tag.Key != ""
+
+		isStruct := field.Type.Kind() == reflect.Struct &&
+			(!tagged || !internal.IsValueStruct(field.Type, tag, conv))
+		isStructPointer := field.Type.Kind() == reflect.Pointer && field.Type.Elem().Kind() == reflect.Struct &&
+			(!tagged || !internal.IsValueStruct(field.Type, tag, conv))
+		unexported := parentUnexported || !field.IsExported() && !(field.Anonymous && (isStruct || isStructPointer))
+		scoped := parentScoped || tag.Baggage
+		fieldPrefix := prefix
+		if tag.Prefix && tag.Key != "" {
+			fieldPrefix = prefix + tag.Key + "."
+		}
+		var isZero func(fieldValue reflect.Value) bool
+		if tag.OmitZero {
+			isZero = internal.ZeroChecker(field.Type)
+		}
+
+		attributer := internal.Implements(field.Type, attributerType)
+		baggager := internal.Implements(field.Type, baggagerType)
+
+		switch {
+		case attributer || baggager:
+			fp := fieldPlan{
+				index:      index,
+				depth:      len(parentIndex),
+				name:       name,
+				unexported: unexported,
+				node:       true,
+				attributer: attributer,
+				baggager:   baggager,
+				merge:      tag.Merge,
+				prefix:     fieldPrefix,
+				isZero:     isZero,
+				priority:   tag.Priority(),
+
+				baggageScope: scoped,
+			}
+			switch {
+			case field.Type.Kind() == reflect.Struct:
+				fp.elem = field.Type
+			case field.Type.Kind() == reflect.Pointer && field.Type.Elem().Kind() == reflect.Struct:
+				fp.elem, fp.pointer = field.Type.Elem(), true
+			}
+			p.fields = append(p.fields, fp)
+		case isStruct && isZero != nil:
+			// Kept as a node so that the whole struct can be skipped.
+			p.fields = append(p.fields, fieldPlan{
+				index:      index,
+				depth:      len(parentIndex),
+				name:       name,
+				unexported: unexported,
+				node:       true,
+				elem:       field.Type,
+				prefix:     fieldPrefix,
+				isZero:     isZero,
+
+				baggageScope: scoped,
+			})
+		case isStruct:
+			e.appendFieldPlans(p, field.Type, fieldPrefix, index, name, unexported, scoped)
+		case isStructPointer:
+			p.fields = append(p.fields, fieldPlan{
+				index:      index,
+				depth:      len(parentIndex),
+				name:       name,
+				unexported: unexported,
+				node:       true,
+				elem:       field.Type.Elem(),
+				pointer:    true,
+				prefix:     fieldPrefix,
+				isZero:     isZero,
+
+				baggageScope: scoped,
+			})
+		default:
+			if !tagged {
+				continue
+			}
+
+			key := tag.Key
+			if key != "" {
+				key = e.keyPrefix + prefix + key
+			}
+			var keyIDAttr attribute.KeyValue
+			var keyIDMember baggage.Member
+			if tag.Hash && key != "" && e.hashKeyID != "" {
+				keyIDAttr = attribute.String(key+hashKeyIDSuffix, e.hashKeyID)
+				keyIDMember, _ = baggage.NewMemberRaw(key+hashKeyIDSuffix, e.hashKeyID)
+			}
+			max := tag.Max()
+			if max == 0 {
+				max = e.maxLength
+			}
+			if !internal.IsTruncatable(field.Type, tag, conv) {
+				max = 0
+			}
+			var truncatedKey attribute.Key
+			if max > 0 && key != "" {
+				truncatedKey = e.truncationMarker.key(key)
+			}
+			p.fields = append(p.fields, fieldPlan{
+				index:          index,
+				depth:          len(parentIndex),
+				name:           name,
+				typ:            field.Type,
+				key:            key,
+				attrKey:        attribute.Key(key),
+				omitEmpty:      tag.OmitEmpty || e.omitEmpty,
+				isZero:         isZero,
+				redacted:       tag.Redacted(),
+				keyIDAttr:      keyIDAttr,
+				keyIDMember:    keyIDMember,
+				max:            max,
+				truncatedKey:   truncatedKey,
+				joined:         internal.IsJoined(field.Type, tag, conv),
+				priority:       tag.Priority(),
+				baggageScope:   scoped,
+				validMemberKey: internal.ValidBaggageKey(key),
+				span:           internal.SpanAttribute(field.Type, tag, conv),
+				baggage:        internal.BaggageMember(field.Type, tag, conv),
+				parseBaggage:   internal.BaggageValueParser(field.Type, tag, conv),
+				setAttribute:   internal.AttributeValueSetter(field.Type, tag, conv),
+				unexported:     unexported,
+			})
+		}
+	}
+}
+
+// invalidTag returns a copy of the first invalid tag of the plan, nil if all tags are valid.
+// The copy can be safely prefixed with a parent field.
+func (p *typePlan) invalidTag() error {
+	if p.tagErr == nil {
+		return nil
+	}
+
+	err := *p.tagErr
+	return &err
+}
+
+// structValue returns the struct value held by v, dereferencing a pointer if needed.
+// Returns false if v does not hold a struct.
+func structValue(v reflect.Value) (reflect.Value, bool) {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return reflect.Value{}, false
+		}
+		v = v.Elem()
+	}
+
+	return v, v.Kind() == reflect.Struct
+}
+
+// fieldValue returns the value of the field located at the provided index path.
+func fieldValue(structValue reflect.Value, index []int) reflect.Value {
+	if len(index) == 1 {
+		return structValue.Field(index[0])
+	}
+
+	return structValue.FieldByIndex(index)
+}
+
+// marshalStructValue returns the struct value held by v for the strict Marshal functions.
+// Returns an invalid value and no error for a nil pointer to a struct.
+func marshalStructValue(v any) (reflect.Value, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Pointer && rv.Type().Elem().Kind() == reflect.Struct && rv.IsNil() {
+		return reflect.Value{}, nil
+	}
+
+	structValue, ok := structValue(rv)
+	if !ok {
+		return reflect.Value{}, &UnsupportedTypeError{Type: reflect.TypeOf(v)}
+	}
+
+	return structValue, nil
+}
+
+// unmarshalStructValue returns the struct value pointed to by v for the Unmarshal functions.
+func unmarshalStructValue(v any) (reflect.Value, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return reflect.Value{}, &InvalidUnmarshalError{Type: reflect.TypeOf(v)}
+	}
+
+	return rv.Elem(), nil
+}
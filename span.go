@@ -1,86 +1,260 @@
 package oteltag
 
 import (
+	"context"
 	"reflect"
-	"strings"
+	"slices"
 
 	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/remychantenay/otel-tag/internal"
 )
 
 // SpanAttributes takes in a struct and spits out OpenTelemetry span attributes ([attribute.KeyValue])
 // based on the struct tags.
+//
+// Fields that cannot be converted are silently ignored, see [MarshalAttributes] for a strict alternative.
 func SpanAttributes(res any) []attribute.KeyValue {
-	return structToAttributes(res)
+	structValue, ok := structValue(reflect.ValueOf(res))
+	if !ok {
+		return nil
+	}
+
+	attrs, _ := defaultEncoder.rootToAttributes(structValue, false)
+	return attrs
+}
+
+// MarshalAttributes takes in a struct (or a pointer to a struct) and returns its OpenTelemetry span attributes
+// ([attribute.KeyValue]) based on the struct tags.
+//
+// Unlike [SpanAttributes], it returns an error instead of ignoring fields that cannot be converted:
+// an [*UnsupportedTypeError] for values or tagged fields of unsupported types,
+// an [*InvalidTagError] for tags holding unknown or malformed options and
+// an [*InvalidKeyError] for tags holding an invalid key.
+func MarshalAttributes(v any) ([]attribute.KeyValue, error) {
+	structValue, err := marshalStructValue(v)
+	if err != nil || !structValue.IsValid() {
+		return nil, err
+	}
+
+	return defaultEncoder.rootToAttributes(structValue, true)
 }
 
-// structToAttributes returns a slice of [attribute.KeyValue] for a struct.
-func structToAttributes(s any) []attribute.KeyValue {
-	structValue := reflect.ValueOf(s)
-	structType := structValue.Type()
+// Start starts a span with tracer and the provided options, like [trace.Tracer.Start], and sets
+// the span attributes of v, a struct or a pointer to a struct, based on the struct tags.
+//
+// The members of the fields tagged with baggage (e.g. `otel:"app.tenant.id,baggage"`), or nested in a struct
+// tagged so, are merged into the baggage of the returned context, see [ContextWithBaggage].
+// Fields that cannot be converted and members conflicting with [MergeError] are silently ignored.
+//
+// v is not walked at all when the span is not recording: its attributes are set after the span is started,
+// they are therefore not visible to samplers, and no baggage is set.
+func Start(ctx context.Context, tracer trace.Tracer, name string, v any, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return defaultEncoder.Start(ctx, tracer, name, v, opts...)
+}
 
-	// Handle pointer to struct
-	if structType.Kind() == reflect.Pointer {
-		if structValue.IsNil() {
-			return nil
+// rootToAttributes returns the [attribute.KeyValue] of a top-level struct.
+func (e *Encoder) rootToAttributes(structValue reflect.Value, strict bool) ([]attribute.KeyValue, error) {
+	var attrs []attribute.KeyValue
+	if e.planFor(structValue.Type(), "").attributer {
+		attrs = customAttributes(structValue)
+		if !e.rootMerge {
+			return attrs, nil
 		}
-		structValue = structValue.Elem()
-		structType = structValue.Type()
 	}
 
-	// Validate input is a struct
-	if structType.Kind() != reflect.Struct {
-		return nil
+	return e.structToAttributes(structValue, "", 0, attrs, strict)
+}
+
+// structToAttributes appends the [attribute.KeyValue] of a struct located depth levels below the top-level struct to attrs,
+// prefixing the keys of its fields with prefix.
+// If strict is false, fields that cannot be converted are skipped instead of returning an error.
+func (e *Encoder) structToAttributes(structValue reflect.Value, prefix string, depth int, attrs []attribute.KeyValue, strict bool) ([]attribute.KeyValue, error) {
+	plan := e.planFor(structValue.Type(), prefix)
+	if strict && plan.tagErr != nil {
+		return nil, plan.invalidTag()
+	}
+	if len(plan.fields) == 0 {
+		return attrs, nil
 	}
 
-	fieldCount := structValue.NumField()
-	if fieldCount == 0 {
-		return nil
+	if attrs == nil {
+		attrs = make([]attribute.KeyValue, 0, len(plan.fields))
 	}
 
-	attrs := make([]attribute.KeyValue, 0, fieldCount)
-	for i := 0; i < fieldCount; i++ {
-		field, fieldValue := structType.Field(i), structValue.Field(i)
-		if field.Type.Kind() == reflect.Struct && fieldValue.IsValid() {
-			attrs = append(attrs, structToAttributes(fieldValue.Interface())...)
-		} else if field.Type.Kind() == reflect.Pointer && fieldValue.IsValid() && !fieldValue.IsNil() { // Known shortcoming, assuming a pointer can only be a struct.
-			attrs = append(attrs, structToAttributes(fieldValue.Elem().Interface())...)
-		} else {
-			attr := basicTypeToAttribute(structType, structValue, i)
-			if !attr.Valid() {
+	for i := range plan.fields {
+		field := &plan.fields[i]
+		if field.unexported && !e.unexportedFields || e.tooDeep(field, depth) {
+			continue
+		}
+
+		fieldValue := fieldValue(structValue, field.index)
+		if field.isZero != nil && field.isZero(fieldValue) {
+			continue
+		}
+
+		if field.node {
+			if internal.IsNil(fieldValue) {
+				continue
+			}
+
+			if field.attributer {
+				attrs = append(attrs, customAttributes(fieldValue)...)
+				if !field.merge {
+					continue
+				}
+			}
+
+			if field.elem == nil {
 				continue
 			}
+			if field.pointer {
+				fieldValue = fieldValue.Elem()
+			}
+
+			var err error
+			attrs, err = e.structToAttributes(fieldValue, field.prefix, depth+field.depth+1, attrs, strict)
+			if err != nil {
+				return nil, withParentField(err, field.name)
+			}
+			continue
+		}
+
+		attr, ok, err := basicTypeToAttribute(field, fieldValue)
+		if err != nil && strict {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+
+		var originalLength int
+		if field.max > 0 {
+			attr, originalLength = internal.TruncateAttribute(attr, field.max)
+		}
 
-			attrs = append(attrs, attr)
+		attrs = append(attrs, attr)
+		if field.truncatedKey != "" && originalLength >= 0 {
+			attrs = append(attrs, attribute.KeyValue{Key: field.truncatedKey, Value: e.truncationMarker.value(originalLength)})
+		}
+		if field.keyIDAttr.Valid() {
+			attrs = append(attrs, field.keyIDAttr)
 		}
 	}
 
-	return attrs
+	return attrs, nil
 }
 
 // basicTypeToAttribute returns an [attribute.KeyValue] for a basic type.
-func basicTypeToAttribute(structType reflect.Type, structValue reflect.Value, index int) attribute.KeyValue {
-	tag := internal.ExtractTag(structValue, index)
+// Returns false if the field should not produce any attribute.
+func basicTypeToAttribute(field *fieldPlan, fieldValue reflect.Value) (attribute.KeyValue, bool, error) {
+	if field.span == nil {
+		return attribute.KeyValue{}, false, &UnsupportedTypeError{Field: field.name, Type: field.typ}
+	}
+	if field.key == "" {
+		return attribute.KeyValue{}, false, &InvalidKeyError{Field: field.name, Key: field.key}
+	}
+
+	attr, zeroValue := field.span(field.attrKey, fieldValue)
+	if zeroValue && field.omitEmpty {
+		return attribute.KeyValue{}, false, nil
+	}
+
+	return attr, attr.Valid(), nil
+}
+
+// UnmarshalAttributes fills the struct pointed to by v with the provided span attributes,
+// based on the struct tags. It is the inverse of [SpanAttributes].
+//
+// Fields without a matching attribute, as well as redacted or masked fields, are left untouched.
+// Nil pointers to structs are allocated only if at least one of their fields has a matching attribute.
+// Returns an [*InvalidUnmarshalError] if v is not a non-nil pointer to a struct and
+// an [*UnmarshalTypeError] if an attribute type does not match its field type.
+//
+// Attributes without a matching field are ignored, see [WithStrictKeys] to report them as a [*KeyMismatchError].
+func UnmarshalAttributes(attrs []attribute.KeyValue, v any) error {
+	return defaultEncoder.UnmarshalAttributes(attrs, v)
+}
+
+// UnmarshalSpan fills the struct pointed to by v with the attributes of the provided span,
+// see [UnmarshalAttributes].
+func UnmarshalSpan(span sdktrace.ReadOnlySpan, v any) error {
+	return UnmarshalAttributes(span.Attributes(), v)
+}
+
+// decodeAttributes sets the fields of a struct from the provided span attributes, see [UnmarshalAttributes].
+func (e *Encoder) decodeAttributes(attrs []attribute.KeyValue, structValue reflect.Value) error {
+	values := make(map[attribute.Key]attribute.Value, len(attrs))
+	for _, attr := range attrs {
+		values[attr.Key] = attr.Value
+	}
+
+	var missing []string
+	used := make(map[attribute.Key]struct{}, len(attrs))
+	_, err := e.decodeStruct(structValue, "", 0, nil, &missing, func(field *fieldPlan, fieldValue reflect.Value) (bool, error) {
+		if field.key == "" {
+			return false, nil
+		}
+
+		// Companion attributes are expected, although not decoded.
+		if field.keyIDAttr.Valid() {
+			used[field.keyIDAttr.Key] = struct{}{}
+		}
+		if field.truncatedKey != "" {
+			used[field.truncatedKey] = struct{}{}
+		}
+
+		attrValue, ok := values[field.attrKey]
+		if field.redacted {
+			// Redacted values cannot be decoded, their attributes are expected though.
+			if ok {
+				used[field.attrKey] = struct{}{}
+			}
+			return false, nil
+		}
+		if !ok {
+			if !field.omitEmpty && field.isZero == nil {
+				missing = append(missing, field.key)
+			}
+			return false, nil
+		}
+		used[field.attrKey] = struct{}{}
+
+		if field.setAttribute == nil {
+			return false, &UnsupportedTypeError{Field: field.name, Type: field.typ}
+		}
+
+		if err := field.setAttribute(attrValue, fieldValue); err != nil {
+			typeErr := &UnmarshalTypeError{
+				Field: field.name,
+				Key:   field.key,
+				Value: attrValue.Type().String() + " attribute",
+				Type:  field.typ,
+			}
+			if err != internal.ErrAttributeTypeMismatch {
+				typeErr.Err = err
+			}
+			return false, typeErr
+		}
 
-	if tag == "" {
-		return attribute.KeyValue{}
+		return true, nil
+	})
+	if err != nil || !e.strictKeys {
+		return err
 	}
 
-	var omitEmpty bool
-	before, after, found := strings.Cut(tag, ",")
-	if found {
-		tag = before
-		if after == flagOmitEmpty {
-			omitEmpty = true
+	var unknown []string
+	for _, attr := range attrs {
+		if _, ok := used[attr.Key]; !ok && !slices.Contains(unknown, string(attr.Key)) {
+			unknown = append(unknown, string(attr.Key))
 		}
 	}
 
-	field, fieldValue := structType.Field(index), structValue.Field(index)
-	attr, zeroValue := internal.SpanAttribute(field, fieldValue, tag)
-	if zeroValue && omitEmpty {
-		return attribute.KeyValue{}
+	if len(unknown) > 0 || len(missing) > 0 {
+		return &KeyMismatchError{Unknown: unknown, Missing: missing}
 	}
 
-	return attr
+	return nil
 }
@@ -2,10 +2,20 @@ package oteltag_test
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/netip"
+	"net/url"
+	"reflect"
 	"slices"
+	"sync"
 	"testing"
+	"time"
 
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 	"go.opentelemetry.io/otel/trace"
@@ -265,4 +275,857 @@ func TestSpanAttributes(t *testing.T) {
 			}
 		}
 	})
+
+	t.Run("when pointers to basic types - should dereference them", func(t *testing.T) {
+		str, num, tags := "a_string", 0, []string{"a", "b"}
+
+		m := struct {
+			ValStr           *string   `otel:"val_str,omitempty"`
+			ValIntZero       *int      `otel:"val_int_zero,omitempty"`
+			ValStrSlice      *[]string `otel:"val_str_slice"`
+			ValNilOmitted    *bool     `otel:"val_nil_omitted,omitempty"`
+			ValNilNotOmitted *float64  `otel:"val_nil_not_omitted"`
+		}{
+			ValStr:      &str,
+			ValIntZero:  &num,
+			ValStrSlice: &tags,
+		}
+
+		want := []attribute.KeyValue{
+			attribute.String("val_str", "a_string"),
+			attribute.Int("val_int_zero", 0),
+			attribute.StringSlice("val_str_slice", []string{"a", "b"}),
+			attribute.Float64("val_nil_not_omitted", 0),
+		}
+
+		got := oteltag.SpanAttributes(m)
+		if !slices.Equal(got, want) {
+			t.Errorf("\ngot %v\nwant %v", got, want)
+		}
+	})
+
+	t.Run("when all numeric kinds - should add attributes to span", func(t *testing.T) {
+		m := numericTestModel{
+			ValInt8:         -8,
+			ValInt16:        -16,
+			ValInt32:        -32,
+			ValUint:         1,
+			ValUint8:        8,
+			ValUint16:       16,
+			ValUint32:       32,
+			ValUint64:       64,
+			ValUint64Max:    math.MaxUint64,
+			ValFloat32:      0.1,
+			ValInt32Slice:   []int32{1, 2, 3},
+			ValUint16Slice:  []uint16{4, 5, 6},
+			ValUint64Slice:  []uint64{7, math.MaxUint64},
+			ValFloat32Slice: []float32{1.1, 2.2},
+		}
+
+		want := []attribute.KeyValue{
+			attribute.Int64("val_int8", -8),
+			attribute.Int64("val_int16", -16),
+			attribute.Int64("val_int32", -32),
+			attribute.Int64("val_uint", 1),
+			attribute.Int64("val_uint8", 8),
+			attribute.Int64("val_uint16", 16),
+			attribute.Int64("val_uint32", 32),
+			attribute.Int64("val_uint64", 64),
+			attribute.String("val_uint64_max", "18446744073709551615"),
+			attribute.Float64("val_float32", 0.1),
+			attribute.Int64Slice("val_int32_slice", []int64{1, 2, 3}),
+			attribute.Int64Slice("val_uint16_slice", []int64{4, 5, 6}),
+			attribute.StringSlice("val_uint64_slice", []string{"7", "18446744073709551615"}),
+			attribute.Float64Slice("val_float32_slice", []float64{1.1, 2.2}),
+		}
+
+		got := oteltag.SpanAttributes(m)
+		if !slices.Equal(got, want) {
+			t.Errorf("\ngot %v\nwant %v", got, want)
+		}
+
+		var decoded numericTestModel
+		if err := oteltag.UnmarshalAttributes(got, &decoded); err != nil {
+			t.Fatalf("\ngot error %v\nwant nil", err)
+		}
+
+		if !reflect.DeepEqual(decoded, m) {
+			t.Errorf("\ngot %+v\nwant %+v", decoded, m)
+		}
+	})
+
+	t.Run("when named types - should add attributes to span", func(t *testing.T) {
+		m := namedTestModel{
+			Role:   "admin",
+			Roles:  testRoles{"admin", "editor"},
+			Levels: testLevels{1, 2},
+			Ratios: []testRatio{0.5, 1.5},
+			Flags:  []testFlag{true, false},
+		}
+
+		want := []attribute.KeyValue{
+			attribute.String("role", "admin"),
+			attribute.StringSlice("roles", []string{"admin", "editor"}),
+			attribute.Int64Slice("levels", []int64{1, 2}),
+			attribute.Float64Slice("ratios", []float64{0.5, 1.5}),
+			attribute.BoolSlice("flags", []bool{true, false}),
+		}
+
+		got := oteltag.SpanAttributes(m)
+		if !slices.Equal(got, want) {
+			t.Errorf("\ngot %v\nwant %v", got, want)
+		}
+
+		var decoded namedTestModel
+		if err := oteltag.UnmarshalAttributes(got, &decoded); err != nil {
+			t.Fatalf("\ngot error %v\nwant nil", err)
+		}
+
+		if !reflect.DeepEqual(decoded, m) {
+			t.Errorf("\ngot %+v\nwant %+v", decoded, m)
+		}
+	})
+
+	t.Run("when unexported and embedded fields - should skip unexported fields without panicking", func(t *testing.T) {
+		want := []attribute.KeyValue{
+			attribute.String("base.id", "123"),
+			attribute.String("name", "john_doe"),
+		}
+
+		got := oteltag.SpanAttributes(newUnexportedTestModel())
+		if !slices.Equal(got, want) {
+			t.Errorf("\ngot %v\nwant %v", got, want)
+		}
+	})
+
+	t.Run("when unexported fields and opted-in - should add them to span", func(t *testing.T) {
+		want := []attribute.KeyValue{
+			attribute.String("base.id", "123"),
+			attribute.String("details.bio", "dev"),
+			attribute.StringSlice("tags", []string{"a", "b"}),
+			attribute.String("name", "john_doe"),
+		}
+
+		got := oteltag.NewEncoder(oteltag.WithUnexportedFields()).SpanAttributes(newUnexportedTestModel())
+		if !slices.Equal(got, want) {
+			t.Errorf("\ngot %v\nwant %v", got, want)
+		}
+	})
+
+	t.Run("when time and duration fields - should format them as specified", func(t *testing.T) {
+		createdAt := time.Date(2024, time.March, 1, 12, 30, 45, 123000000, time.UTC)
+		createdOn := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+		m := timeTestModel{
+			CreatedAt:     createdAt,
+			CreatedAtUnix: createdAt.Truncate(time.Second),
+			CreatedAtMs:   createdAt,
+			CreatedOn:     &createdOn,
+			Timeout:       1500 * time.Millisecond,
+			TimeoutMs:     1500 * time.Millisecond,
+			TimeoutS:      1500 * time.Millisecond,
+		}
+
+		want := []attribute.KeyValue{
+			attribute.String("created_at", "2024-03-01T12:30:45.123Z"),
+			attribute.Int64("created_at_unix", 1709296245),
+			attribute.Int64("created_at_ms", 1709296245123),
+			attribute.String("created_on", "2024-03-01"),
+			attribute.Int64("timeout", 1500000000),
+			attribute.Int64("timeout_ms", 1500),
+			attribute.Float64("timeout_s", 1.5),
+		}
+
+		got := oteltag.SpanAttributes(m)
+		if !slices.Equal(got, want) {
+			t.Errorf("\ngot %v\nwant %v", got, want)
+		}
+
+		var decoded timeTestModel
+		if err := oteltag.UnmarshalAttributes(got, &decoded); err != nil {
+			t.Fatalf("\ngot error %v\nwant nil", err)
+		}
+
+		if !equalTimeTestModels(decoded, m) {
+			t.Errorf("\ngot %+v\nwant %+v", decoded, m)
+		}
+	})
+
+	t.Run("when nil stringers - should add empty strings without panicking", func(t *testing.T) {
+		u, _ := url.Parse("https://example.com")
+		m := struct {
+			Nil      fmt.Stringer `otel:"nil"`
+			TypedNil fmt.Stringer `otel:"typed_nil"`
+			URLs     []*url.URL   `otel:"urls"`
+		}{
+			TypedNil: (*url.URL)(nil),
+			URLs:     []*url.URL{nil, u},
+		}
+
+		want := []attribute.KeyValue{
+			attribute.String("nil", ""),
+			attribute.String("typed_nil", ""),
+			attribute.StringSlice("urls", []string{"", "https://example.com"}),
+		}
+
+		got := oteltag.SpanAttributes(m)
+		if !slices.Equal(got, want) {
+			t.Errorf("\ngot %v\nwant %v", got, want)
+		}
+	})
+
+	t.Run("when text marshalers and stringers - should add string attributes to span", func(t *testing.T) {
+		m := textTestModel{
+			Status:    1,
+			StatusRaw: 2,
+			Statuses:  []testStatus{1, 2},
+			ID:        testID{0xde, 0xad, 0xbe, 0xef},
+			IP:        net.IPv4(192, 168, 0, 1),
+			Addr:      netip.MustParseAddr("10.0.0.1"),
+			URL:       url.URL{Scheme: "https", Host: "example.com", Path: "/users"},
+		}
+
+		want := []attribute.KeyValue{
+			attribute.String("status", "active"),
+			attribute.Int64("status_raw", 2),
+			attribute.StringSlice("statuses", []string{"active", "suspended"}),
+			attribute.String("id", "deadbeef"),
+			attribute.String("ip", "192.168.0.1"),
+			attribute.String("addr", "10.0.0.1"),
+			attribute.String("url", "https://example.com/users"),
+		}
+
+		got := oteltag.SpanAttributes(m)
+		if !slices.Equal(got, want) {
+			t.Errorf("\ngot %v\nwant %v", got, want)
+		}
+
+		var decoded struct {
+			IP   net.IP     `otel:"ip"`
+			Addr netip.Addr `otel:"addr"`
+		}
+		if err := oteltag.UnmarshalAttributes(got, &decoded); err != nil {
+			t.Fatalf("\ngot error %v\nwant nil", err)
+		}
+
+		if !decoded.IP.Equal(m.IP) || decoded.Addr != m.Addr {
+			t.Errorf("\ngot %+v\nwant IP %v and addr %v", decoded, m.IP, m.Addr)
+		}
+	})
+
+	t.Run("when attributers - should replace or merge their attributes", func(t *testing.T) {
+		want := []attribute.KeyValue{
+			attribute.Int("cart.size", 2),
+			attribute.String("cart.id", "c1"),
+			attribute.Int64Slice("cart.items", []int64{1, 2}),
+			attribute.Int("cart.size", 1),
+			attribute.String("name", "john_doe"),
+		}
+
+		m := customTestModel{
+			Cart:        testCart{ID: "c1", Items: []int64{1, 2}},
+			ReplaceCart: &testCart{ID: "c2", Items: []int64{3}},
+			Name:        "john_doe",
+		}
+
+		got := oteltag.SpanAttributes(m)
+		if !slices.Equal(got, want) {
+			t.Errorf("\ngot %v\nwant %v", got, want)
+		}
+	})
+
+	t.Run("when nil attributer interfaces - should skip them without panicking", func(t *testing.T) {
+		want := []attribute.KeyValue{attribute.String("name", "john_doe")}
+
+		m := struct {
+			Nil      oteltag.Attributer `otel:"nil"`
+			TypedNil oteltag.Attributer `otel:"typed_nil"`
+			Name     string             `otel:"name"`
+		}{
+			TypedNil: (*testCart)(nil),
+			Name:     "john_doe",
+		}
+
+		got := oteltag.SpanAttributes(m)
+		if !slices.Equal(got, want) {
+			t.Errorf("\ngot %v\nwant %v", got, want)
+		}
+	})
+
+	t.Run("when top-level attributer - should replace its attributes", func(t *testing.T) {
+		want := []attribute.KeyValue{attribute.Int("cart.size", 3)}
+
+		got := oteltag.SpanAttributes(testCart{ID: "c1", Items: []int64{1, 2, 3}})
+		if !slices.Equal(got, want) {
+			t.Errorf("\ngot %v\nwant %v", got, want)
+		}
+	})
+
+	t.Run("when prefixed nested structs - should prefix the keys of their fields", func(t *testing.T) {
+		want := []attribute.KeyValue{
+			attribute.String("id", "42"),
+			attribute.String("app.home.city", "Paris"),
+			attribute.String("app.home.geo.country", "FR"),
+			attribute.String("app.work.city", "Lyon"),
+			attribute.String("app.work.geo.country", "FR"),
+			attribute.String("city", "Nice"),
+			attribute.String("geo.country", "FR"),
+		}
+
+		got := oteltag.SpanAttributes(newPrefixTestModel())
+		if !slices.Equal(got, want) {
+			t.Errorf("\ngot %v\nwant %v", got, want)
+		}
+	})
+
+	t.Run("when omitzero - should skip zero values and zero nested structs", func(t *testing.T) {
+		tests := []struct {
+			m    zeroTestModel
+			want []attribute.KeyValue
+		}{
+			{
+				m: zeroTestModel{ID: -1, Tags: []string{}},
+				want: []attribute.KeyValue{
+					attribute.StringSlice("order.tags", []string{}),
+					attribute.Int("order.count", 0),
+				},
+			},
+			{
+				m: zeroTestModel{
+					ID:        7,
+					Note:      "fragile",
+					CreatedAt: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+					Shipment:  testShipment{Carrier: "ups"},
+					Return:    &testShipment{},
+				},
+				want: []attribute.KeyValue{
+					attribute.Int64("order.id", 7),
+					attribute.String("order.note", "fragile"),
+					attribute.String("order.created_at", "2024-03-01T12:00:00Z"),
+					attribute.String("shipment.carrier", "ups"),
+					attribute.Int("shipment.weight", 0),
+					attribute.String("shipment.carrier", ""),
+					attribute.Int("shipment.weight", 0),
+					attribute.Int("order.count", 0),
+				},
+			},
+		}
+
+		for _, tt := range tests {
+			got := oteltag.SpanAttributes(tt.m)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("\ngot %v\nwant %v", got, tt.want)
+			}
+		}
+	})
+
+	t.Run("when redacted and masked fields - should hide their values", func(t *testing.T) {
+		want := []attribute.KeyValue{
+			attribute.String("user.email", "[REDACTED]"),
+			attribute.String("user.age", "[REDACTED]"),
+			attribute.String("user.card", "************1111"),
+			attribute.String("user.phone", "061*******"),
+			attribute.String("user.pin", "****"),
+			attribute.StringSlice("user.tokens", []string{"**cd", "**gh"}),
+			attribute.String("user.username", "john_doe"),
+		}
+
+		got := oteltag.SpanAttributes(newRedactTestModel())
+		if !slices.Equal(got, want) {
+			t.Errorf("\ngot %v\nwant %v", got, want)
+		}
+	})
+
+	t.Run("when max lengths - should truncate strings and cap slices", func(t *testing.T) {
+		want := []attribute.KeyValue{
+			attribute.String("order.description", "crème"),
+			attribute.String("order.note", "short"),
+			attribute.StringSlice("order.tags", []string{"a", "b"}),
+			attribute.IntSlice("order.ids", []int{1, 2}),
+			attribute.String("order.title", "a long title"),
+		}
+
+		got := oteltag.SpanAttributes(newTruncateTestModel())
+		if !slices.Equal(got, want) {
+			t.Errorf("\ngot %v\nwant %v", got, want)
+		}
+	})
+
+	t.Run("when fields tagged with - - should ignore them and not walk struct fields", func(t *testing.T) {
+		type secret struct {
+			Token string `otel:"secret.token"`
+		}
+
+		m := struct {
+			ID       string  `otel:"id"`
+			Password string  `otel:"-"`
+			Secret   secret  `otel:"-"`
+			Secrets  *secret `otel:"-"`
+			Dash     string  `otel:"-,"`
+		}{
+			ID:       "42",
+			Password: "hunter2",
+			Secret:   secret{Token: "t1"},
+			Secrets:  &secret{Token: "t2"},
+			Dash:     "dash",
+		}
+
+		want := []attribute.KeyValue{
+			attribute.String("id", "42"),
+			attribute.String("-", "dash"),
+		}
+
+		got := oteltag.SpanAttributes(m)
+		if !slices.Equal(got, want) {
+			t.Errorf("\ngot %v\nwant %v", got, want)
+		}
+	})
+
+	t.Run("when multiple and invalid options - should apply the valid ones", func(t *testing.T) {
+		m := struct {
+			ValStr   string        `otel:"val_str,raw,omitempty"`
+			ValInt   int           `otel:"val_int,omitmpty,omitempty"`
+			Duration time.Duration `otel:"duration,unit=ms,omitempty,unit=hours"`
+		}{
+			Duration: 1500 * time.Millisecond,
+		}
+
+		want := []attribute.KeyValue{attribute.Int64("duration", 1500)}
+
+		got := oteltag.SpanAttributes(m)
+		if !slices.Equal(got, want) {
+			t.Errorf("\ngot %v\nwant %v", got, want)
+		}
+	})
+
+	t.Run("when self-referencing struct pointers - should add attributes of each level to span", func(t *testing.T) {
+		const wantAttributeCount = 2
+
+		type node struct {
+			Name string `otel:"node.name"`
+			Next *node
+		}
+
+		m := node{Name: "head", Next: &node{Name: "tail"}}
+
+		attrs := oteltag.SpanAttributes(m)
+		if len(attrs) != wantAttributeCount {
+			t.Fatalf("\ngot %d attributes\nwant %d", len(attrs), wantAttributeCount)
+		}
+
+		if attrs[0].Value.AsString() != "head" || attrs[1].Value.AsString() != "tail" {
+			t.Errorf("\ngot %v\nwant head then tail", attrs)
+		}
+	})
+
+	t.Run("when called concurrently - should return the same attributes", func(t *testing.T) {
+		m := testModel{ValStr: "a_string", ValInt: 42}
+		want := oteltag.SpanAttributes(m)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if got := oteltag.SpanAttributes(m); !slices.Equal(got, want) {
+					t.Errorf("\ngot %v\nwant %v", got, want)
+				}
+			}()
+		}
+		wg.Wait()
+	})
+}
+
+func TestStart(t *testing.T) {
+	const testOperationName = "span"
+
+	type session struct {
+		ID     string `otel:"app.session.id"`
+		Device string `otel:"app.session.device"`
+	}
+
+	type request struct {
+		TenantID string   `otel:"app.tenant.id,baggage"`
+		UserID   string   `otel:"app.user.id"`
+		Session  *session `otel:",baggage"`
+	}
+
+	t.Run("when recording span - should set attributes and scoped baggage", func(t *testing.T) {
+		wantAttrs := []attribute.KeyValue{
+			attribute.String("app.tenant.id", "acme"),
+			attribute.String("app.user.id", "42"),
+			attribute.String("app.session.id", "s1"),
+			attribute.String("app.session.device", "mobile"),
+		}
+		wantMembers := []string{"app.request.id=r1", "app.session.device=mobile", "app.session.id=s1", "app.tenant.id=acme"}
+
+		spanRecorder := tracetest.NewSpanRecorder()
+		tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spanRecorder)).Tracer("test-tracer")
+
+		member, _ := baggage.NewMemberRaw("app.request.id", "r1")
+		bag, _ := baggage.New(member)
+		ctx := baggage.ContextWithBaggage(context.Background(), bag)
+
+		m := request{
+			TenantID: "acme",
+			UserID:   "42",
+			Session:  &session{ID: "s1", Device: "mobile"},
+		}
+
+		ctx, span := oteltag.Start(ctx, tracer, testOperationName, m)
+		span.End()
+
+		if got := spanRecorder.Ended()[0].Attributes(); !slices.Equal(got, wantAttrs) {
+			t.Errorf("\ngot %v\nwant %v", got, wantAttrs)
+		}
+
+		var gotMembers []string
+		for _, m := range baggage.FromContext(ctx).Members() {
+			gotMembers = append(gotMembers, m.Key()+"="+m.Value())
+		}
+		slices.Sort(gotMembers)
+		if !slices.Equal(gotMembers, wantMembers) {
+			t.Errorf("\ngot %v\nwant %v", gotMembers, wantMembers)
+		}
+
+		if trace.SpanFromContext(ctx) != span {
+			t.Errorf("\ngot a context without the span\nwant the started span")
+		}
+	})
+
+	t.Run("when span start options - should pass them to the tracer", func(t *testing.T) {
+		spanRecorder := tracetest.NewSpanRecorder()
+		tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spanRecorder)).Tracer("test-tracer")
+		startTime := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+		_, span := oteltag.Start(context.Background(), tracer, testOperationName, testModel{ValStr: "a_string"},
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithTimestamp(startTime),
+			trace.WithAttributes(attribute.String("http.method", "GET")),
+		)
+		span.End()
+
+		got := spanRecorder.Ended()[0]
+		if got.SpanKind() != trace.SpanKindServer || !got.StartTime().Equal(startTime) {
+			t.Errorf("\ngot kind %v and start time %v\nwant %v and %v", got.SpanKind(), got.StartTime(), trace.SpanKindServer, startTime)
+		}
+
+		attrs := got.Attributes()
+		if len(attrs) == 0 || attrs[0] != attribute.String("http.method", "GET") {
+			t.Errorf("\ngot %v\nwant http.method first", attrs)
+		}
+		if !slices.Contains(attrs, attribute.String("val_str", "a_string")) {
+			t.Errorf("\ngot %v\nwant val_str", attrs)
+		}
+	})
+
+	t.Run("when no field tagged with baggage - should not set baggage", func(t *testing.T) {
+		tracer := sdktrace.NewTracerProvider().Tracer("test-tracer")
+
+		ctx, span := oteltag.Start(context.Background(), tracer, testOperationName, testModel{ValStr: "a_string"})
+		span.End()
+
+		if members := baggage.FromContext(ctx).Members(); len(members) != 0 {
+			t.Errorf("\ngot %d members\nwant 0", len(members))
+		}
+	})
+
+	t.Run("when span not recording - should not walk the struct", func(t *testing.T) {
+		tracer := sdktrace.NewTracerProvider(sdktrace.WithSampler(sdktrace.NeverSample())).Tracer("test-tracer")
+
+		var calls int
+		_, span := oteltag.Start(context.Background(), tracer, testOperationName, countingAttributer{calls: &calls})
+		span.End()
+
+		if calls != 0 {
+			t.Errorf("\ngot %d calls\nwant 0", calls)
+		}
+
+		m := request{
+			TenantID: "acme",
+			UserID:   "42",
+			Session:  &session{ID: "s1", Device: "mobile"},
+		}
+
+		ctx, span := oteltag.Start(context.Background(), tracer, testOperationName, m)
+		span.End()
+
+		if members := baggage.FromContext(ctx).Members(); len(members) != 0 {
+			t.Errorf("\ngot %d members\nwant 0", len(members))
+		}
+	})
+}
+
+func TestMarshalAttributes(t *testing.T) {
+	t.Run("when valid struct - should return all attributes", func(t *testing.T) {
+		const wantAttributeCount = 2
+
+		m := testModel{ValStr: "a_string", ValInt: 42}
+
+		attrs, err := oteltag.MarshalAttributes(&m)
+		if err != nil {
+			t.Fatalf("\ngot error %v\nwant nil", err)
+		}
+
+		if len(attrs) != wantAttributeCount {
+			t.Errorf("\ngot %d attributes\nwant %d", len(attrs), wantAttributeCount)
+		}
+	})
+
+	t.Run("when nil - should return an unsupported type error", func(t *testing.T) {
+		_, err := oteltag.MarshalAttributes(nil)
+
+		var typeErr *oteltag.UnsupportedTypeError
+		if !errors.As(err, &typeErr) {
+			t.Fatalf("\ngot error %v\nwant %T", err, typeErr)
+		}
+	})
+
+	t.Run("when nil struct pointer - should return no attributes", func(t *testing.T) {
+		attrs, err := oteltag.MarshalAttributes((*testModel)(nil))
+		if err != nil || attrs != nil {
+			t.Errorf("\ngot %v, %v\nwant nil, nil", attrs, err)
+		}
+	})
+
+	t.Run("when unsupported field type - should return an unsupported type error with the field path", func(t *testing.T) {
+		const wantField = "Nested.Inner.ValMap"
+
+		type inner struct {
+			ValMap map[string]string `otel:"val_map"`
+		}
+
+		type nested struct {
+			Inner inner
+		}
+
+		m := struct {
+			Nested *nested
+		}{
+			Nested: &nested{Inner: inner{ValMap: map[string]string{"a": "b"}}},
+		}
+
+		_, err := oteltag.MarshalAttributes(m)
+
+		var typeErr *oteltag.UnsupportedTypeError
+		if !errors.As(err, &typeErr) {
+			t.Fatalf("\ngot error %v\nwant %T", err, typeErr)
+		}
+
+		if typeErr.Field != wantField {
+			t.Errorf("\ngot field %q\nwant %q", typeErr.Field, wantField)
+		}
+
+		if attrs := oteltag.SpanAttributes(m); len(attrs) != 0 {
+			t.Errorf("\ngot %d attributes from SpanAttributes\nwant 0", len(attrs))
+		}
+	})
+
+	t.Run("when invalid tag options - should return an invalid tag error", func(t *testing.T) {
+		tests := []struct {
+			tag     string
+			wantOpt string
+			wantErr error
+		}{
+			{tag: "val,omitmpty", wantOpt: "omitmpty", wantErr: oteltag.ErrUnknownTagOption},
+			{tag: "val,omitempty=true", wantOpt: "omitempty=true", wantErr: oteltag.ErrMalformedTagOption},
+			{tag: "val,,omitempty", wantOpt: "", wantErr: oteltag.ErrMalformedTagOption},
+			{tag: "val,format=", wantOpt: "format=", wantErr: oteltag.ErrMalformedTagOption},
+			{tag: "val,unit=hours", wantOpt: "unit=hours", wantErr: oteltag.ErrMalformedTagOption},
+		}
+
+		for _, tt := range tests {
+			typ := reflect.StructOf([]reflect.StructField{{
+				Name: "Inner",
+				Type: reflect.StructOf([]reflect.StructField{{
+					Name: "Val",
+					Type: reflect.TypeFor[string](),
+					Tag:  reflect.StructTag(`otel:"` + tt.tag + `"`),
+				}}),
+			}})
+
+			_, err := oteltag.MarshalAttributes(reflect.New(typ).Interface())
+
+			var tagErr *oteltag.InvalidTagError
+			if !errors.As(err, &tagErr) {
+				t.Fatalf("tag %q\ngot error %v\nwant %T", tt.tag, err, tagErr)
+			}
+
+			if tagErr.Field != "Inner.Val" || tagErr.Option != tt.wantOpt || !errors.Is(err, tt.wantErr) {
+				t.Errorf("tag %q\ngot %v\nwant field Inner.Val, option %q and %v", tt.tag, err, tt.wantOpt, tt.wantErr)
+			}
+		}
+	})
+
+	t.Run("when empty key - should return an invalid key error", func(t *testing.T) {
+		m := struct {
+			ValStr string `otel:",omitempty"`
+		}{
+			ValStr: "a_string",
+		}
+
+		_, err := oteltag.MarshalAttributes(m)
+
+		var keyErr *oteltag.InvalidKeyError
+		if !errors.As(err, &keyErr) {
+			t.Fatalf("\ngot error %v\nwant %T", err, keyErr)
+		}
+
+		if keyErr.Field != "ValStr" {
+			t.Errorf("\ngot field %q\nwant %q", keyErr.Field, "ValStr")
+		}
+	})
+}
+
+func TestUnmarshalAttributes(t *testing.T) {
+	t.Run("when attributes of a recorded span - should fill all fields", func(t *testing.T) {
+		want := testModel{
+			ValStr:          "a_string",
+			ValInt:          42,
+			ValInt64:        42000000000,
+			ValFloat64:      99.718281828,
+			ValBool:         true,
+			ValStrSlice:     []string{"a_string_1", "a_string_2", "a_string_3"},
+			ValIntSlice:     []int{1, 2, 3},
+			ValInt64Slice:   []int64{100000, 200000, 300000},
+			ValFloat64Slice: []float64{1.1, 2.2, 3.3},
+			ValBoolSlice:    []bool{true, false, true, false},
+		}
+
+		spanRecorder := tracetest.NewSpanRecorder()
+		tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spanRecorder)).Tracer("test-tracer")
+
+		_, span := tracer.Start(context.Background(), "span", trace.WithAttributes(oteltag.SpanAttributes(want)...))
+		span.End()
+
+		var got testModel
+		if err := oteltag.UnmarshalSpan(spanRecorder.Ended()[0], &got); err != nil {
+			t.Fatalf("\ngot error %v\nwant nil", err)
+		}
+
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("\ngot %+v\nwant %+v", got, want)
+		}
+	})
+
+	t.Run("when attribute type does not match field type - should return an unmarshal type error", func(t *testing.T) {
+		attrs := []attribute.KeyValue{attribute.String("val_int", "42")}
+
+		var got testModel
+		err := oteltag.UnmarshalAttributes(attrs, &got)
+
+		var typeErr *oteltag.UnmarshalTypeError
+		if !errors.As(err, &typeErr) {
+			t.Fatalf("\ngot error %v\nwant %T", err, typeErr)
+		}
+
+		if typeErr.Field != "ValInt" || typeErr.Key != "val_int" {
+			t.Errorf("\ngot field %q and key %q\nwant %q and %q", typeErr.Field, typeErr.Key, "ValInt", "val_int")
+		}
+	})
+
+	t.Run("when redacted fields - should leave them untouched", func(t *testing.T) {
+		var got redactTestModel
+		if err := oteltag.UnmarshalAttributes(oteltag.SpanAttributes(newRedactTestModel()), &got); err != nil {
+			t.Fatalf("\ngot error %v\nwant nil", err)
+		}
+
+		if want := (redactTestModel{Username: "john_doe"}); !reflect.DeepEqual(got, want) {
+			t.Errorf("\ngot %+v\nwant %+v", got, want)
+		}
+	})
+
+	type user struct {
+		ID   string `otel:"app.user.id"`
+		Name string `otel:"app.user.name"`
+		Bio  string `otel:"app.user.bio,omitempty"`
+		Age  int    `otel:"app.user.age,omitzero"`
+	}
+
+	t.Run("when unknown and missing keys - should fill matching fields without error", func(t *testing.T) {
+		attrs := []attribute.KeyValue{
+			attribute.String("app.user.id", "123"),
+			attribute.String("http.method", "GET"),
+		}
+
+		var got user
+		if err := oteltag.UnmarshalAttributes(attrs, &got); err != nil {
+			t.Fatalf("\ngot error %v\nwant nil", err)
+		}
+
+		if got.ID != "123" {
+			t.Errorf("\ngot ID %q\nwant %q", got.ID, "123")
+		}
+	})
+
+	t.Run("when unknown and missing keys with strict keys - should fill matching fields and report mismatches", func(t *testing.T) {
+		attrs := []attribute.KeyValue{
+			attribute.String("app.user.id", "123"),
+			attribute.String("http.method", "GET"),
+		}
+
+		var got user
+		err := oteltag.NewEncoder(oteltag.WithStrictKeys()).UnmarshalAttributes(attrs, &got)
+
+		var mismatchErr *oteltag.KeyMismatchError
+		if !errors.As(err, &mismatchErr) {
+			t.Fatalf("\ngot error %v\nwant %T", err, mismatchErr)
+		}
+
+		if !slices.Equal(mismatchErr.Unknown, []string{"http.method"}) {
+			t.Errorf("\ngot unknown keys %v\nwant %v", mismatchErr.Unknown, []string{"http.method"})
+		}
+
+		if !slices.Equal(mismatchErr.Missing, []string{"app.user.name"}) {
+			t.Errorf("\ngot missing keys %v\nwant %v", mismatchErr.Missing, []string{"app.user.name"})
+		}
+
+		if got.ID != "123" {
+			t.Errorf("\ngot ID %q\nwant %q", got.ID, "123")
+		}
+	})
+
+	t.Run("when nil pointer and zero omitzero nested structs with strict keys - should round-trip without mismatches", func(t *testing.T) {
+		type order struct {
+			ID       string        `otel:"order.id"`
+			Shipment testShipment  `otel:",omitzero"`
+			Return   *testShipment `otel:"order.return,prefix"`
+		}
+
+		enc := oteltag.NewEncoder(oteltag.WithStrictKeys())
+		in := order{ID: "42"}
+
+		var got order
+		if err := enc.UnmarshalAttributes(enc.SpanAttributes(in), &got); err != nil {
+			t.Fatalf("\ngot error %v\nwant nil", err)
+		}
+
+		if !reflect.DeepEqual(got, in) {
+			t.Errorf("\ngot %+v\nwant %+v", got, in)
+		}
+	})
+
+	t.Run("when partially set nil pointer nested struct with strict keys - should report its missing keys", func(t *testing.T) {
+		type order struct {
+			ID     string        `otel:"order.id"`
+			Return *testShipment `otel:"order.return,prefix"`
+		}
+
+		attrs := []attribute.KeyValue{
+			attribute.String("order.id", "42"),
+			attribute.String("order.return.shipment.carrier", "ups"),
+		}
+
+		var got order
+		err := oteltag.NewEncoder(oteltag.WithStrictKeys()).UnmarshalAttributes(attrs, &got)
+
+		var mismatchErr *oteltag.KeyMismatchError
+		if !errors.As(err, &mismatchErr) {
+			t.Fatalf("\ngot error %v\nwant %T", err, mismatchErr)
+		}
+
+		if want := []string{"order.return.shipment.weight"}; !slices.Equal(mismatchErr.Missing, want) {
+			t.Errorf("\ngot missing keys %v\nwant %v", mismatchErr.Missing, want)
+		}
+	})
 }